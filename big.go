@@ -0,0 +1,220 @@
+package boat
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxBigExp  = 4096    // largest exponent or shift count accepted in big number mode
+	maxBigBits = 1 << 16 // largest numerator or denominator, in bits, of a big number result
+)
+
+func BigIntNode(val *big.Int) Node {
+	return bigNode(new(big.Rat).SetInt(val), true)
+}
+
+func DecimalNode(val *big.Rat) Node {
+	return bigNode(new(big.Rat).Set(val), false)
+}
+
+func bigNode(val *big.Rat, isInt bool) Node {
+	n := Node{Type: nodeFloat, Big: val}
+	if isInt {
+		n.Type = nodeInt
+	}
+	if val.IsInt() && val.Num().IsInt64() {
+		n.Int = val.Num().Int64()
+	}
+	n.Float, _ = val.Float64()
+	return n
+}
+
+func parseBigInt(val string) (Node, error) {
+	i, ok := new(big.Int).SetString(val, 0)
+	if !ok {
+		return Node{}, fmt.Errorf("invalid int %q", val)
+	}
+	return bigNode(new(big.Rat).SetInt(i), true), nil
+}
+
+func parseBigFloat(val string) (Node, error) {
+	lower, exp := strings.ToLower(val), "e"
+	if strings.HasPrefix(strings.TrimLeft(lower, "+-"), "0x") {
+		exp = "p"
+	}
+	if i := strings.Index(lower, exp); i >= 0 {
+		exp, err := strconv.Atoi(strings.Replace(val[i+1:], "_", "", -1))
+		if err != nil || exp > maxBigExp || exp < -maxBigExp {
+			return Node{}, fmt.Errorf("exponent of %q out of range", val)
+		}
+	}
+	r, ok := new(big.Rat).SetString(val)
+	if !ok {
+		return Node{}, fmt.Errorf("invalid decimal %q", val)
+	}
+	return bigNode(r, false), nil
+}
+
+func ratOf(n Node) (*big.Rat, bool) {
+	switch {
+	case n.Big != nil:
+		return n.Big, true
+	case n.Type == nodeInt:
+		return new(big.Rat).SetInt64(n.Int), true
+	case n.Type == nodeFloat:
+		r := new(big.Rat).SetFloat64(n.Float)
+		return r, r != nil
+	}
+	return nil, false
+}
+
+func isBig(a, b Node) bool {
+	return (a.Big != nil || b.Big != nil) &&
+		(a.Type == nodeInt || a.Type == nodeFloat) && (b.Type == nodeInt || b.Type == nodeFloat)
+}
+
+func compareBig(a, b Node) (int, bool) {
+	x, xok := ratOf(a)
+	y, yok := ratOf(b)
+	if !xok || !yok {
+		return compareFloats(a.Float, b.Float)
+	}
+	return x.Cmp(y), true
+}
+
+func bigArith(op TokenType, a, b Node) (Node, error) {
+	x, xok := ratOf(a)
+	y, yok := ratOf(b)
+	if !xok || !yok {
+		return Node{}, fmt.Errorf(`operands of '%s' must be finite numbers`, op)
+	}
+
+	isInt := a.Type == nodeInt && b.Type == nodeInt
+
+	z := new(big.Rat)
+	switch op {
+	case tokPlus:
+		z.Add(x, y)
+	case tokMinus:
+		z.Sub(x, y)
+	case tokMultiply:
+		z.Mul(x, y)
+	case tokDivide:
+		if y.Sign() == 0 {
+			return Node{}, fmt.Errorf(`'%s': %w`, op, ErrDivisionByZero)
+		}
+		if isInt {
+			z.SetInt(new(big.Int).Quo(x.Num(), y.Num()))
+		} else {
+			z.Quo(x, y)
+		}
+	case tokModulo:
+		if y.Sign() == 0 {
+			return Node{}, fmt.Errorf(`'%s': %w`, op, ErrDivisionByZero)
+		}
+		q := new(big.Rat).Quo(x, y)
+		q.SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+		z.Sub(x, q.Mul(q, y))
+	case tokPower:
+		if !y.IsInt() || !y.Num().IsInt64() || y.Num().Int64() > maxBigExp || y.Num().Int64() < -maxBigExp {
+			return Node{}, fmt.Errorf(`exponent %s out of range`, y.RatString())
+		}
+		exp := y.Num().Int64()
+		if exp < 0 && x.Sign() == 0 {
+			return Node{}, fmt.Errorf(`'%s': %w`, op, ErrDivisionByZero)
+		}
+		bits := x.Num().BitLen()
+		if d := x.Denom().BitLen(); d > bits {
+			bits = d
+		}
+		if int64(bits)*abs(exp) > maxBigBits {
+			return Node{}, bigTooLarge(op)
+		}
+		num := new(big.Int).Exp(x.Num(), big.NewInt(abs(exp)), nil)
+		den := new(big.Int).Exp(x.Denom(), big.NewInt(abs(exp)), nil)
+		if exp < 0 {
+			num, den = den, num
+			isInt = false
+		}
+		z.SetFrac(num, den)
+	case tokShiftLeft, tokShiftRight, tokBitAnd, tokBitOr, tokBitXor:
+		if !isInt {
+			return Node{}, fmt.Errorf(`lhs and rhs for '%s' must be int`, op)
+		}
+		i, j := x.Num(), y.Num()
+		switch op {
+		case tokShiftLeft, tokShiftRight:
+			if j.Sign() < 0 || !j.IsInt64() || j.Int64() > maxBigExp {
+				return Node{}, fmt.Errorf(`'%s': %w %s`, op, ErrShiftCount, j)
+			}
+			if op == tokShiftLeft {
+				if i.BitLen()+int(j.Int64()) > maxBigBits {
+					return Node{}, bigTooLarge(op)
+				}
+				z.SetInt(new(big.Int).Lsh(i, uint(j.Int64())))
+			} else {
+				z.SetInt(new(big.Int).Rsh(i, uint(j.Int64())))
+			}
+		case tokBitAnd:
+			z.SetInt(new(big.Int).And(i, j))
+		case tokBitOr:
+			z.SetInt(new(big.Int).Or(i, j))
+		case tokBitXor:
+			z.SetInt(new(big.Int).Xor(i, j))
+		}
+	default:
+		return Node{}, fmt.Errorf(`'%s' is not supported in big number mode`, op)
+	}
+
+	if z.Num().BitLen() > maxBigBits || z.Denom().BitLen() > maxBigBits {
+		return Node{}, bigTooLarge(op)
+	}
+
+	return bigNode(z, isInt), nil
+}
+
+func bigTooLarge(op TokenType) error {
+	return fmt.Errorf(`'%s': result exceeds %d bits: %w`, op, maxBigBits, ErrOverflow)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func formatBig(n Node) string {
+	if n.Type == nodeInt {
+		return n.Big.Num().String()
+	}
+
+	d := new(big.Int).Set(n.Big.Denom())
+	twos, fives := 0, 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	five, m := big.NewInt(5), new(big.Int)
+	for {
+		q, r := new(big.Int).QuoRem(d, five, m)
+		if r.Sign() != 0 {
+			break
+		}
+		d = q
+		fives++
+	}
+
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return n.Big.FloatString(20)
+	}
+
+	prec := twos
+	if fives > prec {
+		prec = fives
+	}
+	return n.Big.FloatString(prec)
+}
@@ -0,0 +1,124 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestBigRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "0.3", rule: `$ == 0.1 + 0.2`, pass: true},
+		{in: "0.3", rule: `<= 0.1 + 0.2`, pass: true},
+		{in: "18446744073709551615", rule: `18446744073709551615`, pass: true},
+		{in: "18446744073709551615", rule: `> 9223372036854775807`, pass: true},
+		{in: "18446744073709551615", rule: `$ - 1 == 0xffff_ffff_ffff_fffe`, pass: true},
+		{in: "18446744073709551616", rule: `0..18446744073709551615`, pass: false},
+		{in: "18446744073709551615", rule: `in [1, 18446744073709551615]`, pass: true},
+		{in: "9223372036854775807", rule: `$ + 1 > 9223372036854775807`, pass: true},
+		{in: "19.99", rule: `$ * 3 == 59.97`, pass: true},
+		{in: "100.00", rule: `$ / 3 * 3 == 100`, pass: true},
+		{in: "7", rule: `$ / 2 == 3`, pass: true},
+		{in: "7", rule: `$ / 2.0 == 3.5`, pass: true},
+		{in: "-0.5", rule: `-1..0 & $ == -0.5 & in [-0.5]`, pass: true},
+		{in: "1e-3", rule: `0.001`, pass: true},
+		{in: "0.25", rule: `0x1p-2`, pass: true},
+		{in: "abc", rule: `$ * 2 == "abcabc"`, pass: true},
+		{in: "abc", rule: `substr($, 1) "bc"`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithBigNumbers())
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestBigDecode(t *testing.T) {
+	n, err := DecodeBig("18446744073709551615")
+	require.NoError(t, err)
+	require.Equal(t, nodeInt, n.Type)
+	require.Equal(t, "18446744073709551615", n.String())
+
+	n, err = DecodeBig("0.10")
+	require.NoError(t, err)
+	require.Equal(t, nodeFloat, n.Type)
+	require.Equal(t, "0.1", n.String())
+
+	_, err = Decode("18446744073709551615")
+	require.Error(t, err)
+
+	_, err = DecodeBig("1e1000000000")
+	require.Error(t, err)
+
+	_, err = DecodeBig("12abc")
+	require.Error(t, err)
+}
+
+func TestBigEnv(t *testing.T) {
+	px, err := ParseRule(`id > 9223372036854775807 & amount == 10.10 & amount + fee == 10.35`, WithBigNumbers())
+	require.NoError(t, err)
+
+	id, _ := new(big.Int).SetString("18446744073709551615", 10)
+	amount, _ := new(big.Rat).SetString("10.10")
+
+	pass, err := px.EvalEnv("", Env{"id": BigIntNode(id), "amount": DecimalNode(amount), "fee": FloatNode(0.25)})
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	pass, err = px.EvalJSON([]byte(`{"id": 18446744073709551615, "amount": 10.10, "fee": 0.25}`))
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	pass, err = px.EvalStruct(struct {
+		ID     uint64
+		Amount *big.Rat
+		Fee    big.Rat
+	}{ID: 1<<64 - 1, Amount: amount, Fee: *big.NewRat(1, 4)})
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+func TestBigErrors(t *testing.T) {
+	px, err := ParseRule(`$ / 0 == 1`, WithBigNumbers())
+	require.NoError(t, err)
+
+	_, err = px.Eval("10")
+	require.Error(t, err)
+
+	_, err = ParseRule(`1e100000`, WithBigNumbers())
+	require.Error(t, err)
+
+	_, err = ParseRule(`18446744073709551615`)
+	require.Error(t, err)
+
+	for _, rule := range []string{
+		`((10 ** 4096) ** 4096) ** 4096 > 1`,
+		`(1 << 4096) << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 << 4096 > 1`,
+		`(10 ** 4096) * (10 ** 4096) * (10 ** 4096) * (10 ** 4096) * (10 ** 4096) * (10 ** 4096) > 1`,
+	} {
+		_, err = ParseRule(rule, WithBigNumbers())
+		require.True(t, errors.Is(err, ErrOverflow), rule)
+
+		var serr *SyntaxError
+		require.True(t, errors.As(err, &serr), rule)
+	}
+
+	px, err = ParseRule(`$ ** 4096 > 1`, WithBigNumbers())
+	require.NoError(t, err)
+
+	_, err = px.Eval("1" + strings.Repeat("0", 4096))
+	require.True(t, errors.Is(err, ErrOverflow))
+
+	var eerr *EvalError
+	require.True(t, errors.As(err, &eerr))
+}
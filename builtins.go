@@ -0,0 +1,178 @@
+package boat
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var builtins = NewRegistry(nil)
+
+func init() {
+	builtins.RegisterFuncArity("len", 1, 1, builtinLen)
+	builtins.RegisterFuncArity("lower", 1, 1, builtinLower)
+	builtins.RegisterFuncArity("upper", 1, 1, builtinUpper)
+	builtins.RegisterFuncArity("trim", 1, 1, builtinTrim)
+	builtins.RegisterFuncArity("startsWith", 2, 2, builtinStartsWith)
+	builtins.RegisterFuncArity("endsWith", 2, 2, builtinEndsWith)
+	builtins.RegisterFuncArity("contains", 2, 2, builtinContains)
+	builtins.RegisterFuncArity("substr", 2, 3, builtinSubstr)
+	builtins.RegisterFuncArity("indexOf", 2, 2, builtinIndexOf)
+	builtins.RegisterFuncArity("replace", 3, 3, builtinReplace)
+
+	text := typeSet(1 << nodeText)
+	builtins.typed("len", 1<<nodeInt, text)
+	builtins.typed("lower", text, text)
+	builtins.typed("upper", text, text)
+	builtins.typed("trim", text, text)
+	builtins.typed("startsWith", 1<<nodeBool, text)
+	builtins.typed("endsWith", 1<<nodeBool, text)
+	builtins.typed("contains", 1<<nodeBool, text)
+	builtins.typed("substr", text, text, 1<<nodeInt)
+	builtins.typed("indexOf", 1<<nodeInt, text)
+	builtins.typed("replace", text, text)
+}
+
+func textArg(args []Node, i int) (string, error) {
+	if args[i].Type != nodeText {
+		return "", fmt.Errorf("arg %d must be text, got %s", i+1, args[i].Type)
+	}
+	return args[i].Text, nil
+}
+
+func intArg(args []Node, i int) (int64, error) {
+	if args[i].Type != nodeInt {
+		return 0, fmt.Errorf("arg %d must be an int, got %s", i+1, args[i].Type)
+	}
+	if args[i].Big != nil && !args[i].Big.Num().IsInt64() {
+		return 0, fmt.Errorf("arg %d is out of range", i+1)
+	}
+	return args[i].Int, nil
+}
+
+func textArgs(args []Node) error {
+	for i := range args {
+		if _, err := textArg(args, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func builtinLen(args ...Node) (Node, error) {
+	s, err := textArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeInt, Int: int64(utf8.RuneCountInString(s))}, nil
+}
+
+func builtinLower(args ...Node) (Node, error) {
+	s, err := textArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeText, Text: strings.ToLower(s)}, nil
+}
+
+func builtinUpper(args ...Node) (Node, error) {
+	s, err := textArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeText, Text: strings.ToUpper(s)}, nil
+}
+
+func builtinTrim(args ...Node) (Node, error) {
+	s, err := textArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeText, Text: strings.TrimSpace(s)}, nil
+}
+
+func builtinStartsWith(args ...Node) (Node, error) {
+	if err := textArgs(args); err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeBool, Bool: strings.HasPrefix(args[0].Text, args[1].Text)}, nil
+}
+
+func builtinEndsWith(args ...Node) (Node, error) {
+	if err := textArgs(args); err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeBool, Bool: strings.HasSuffix(args[0].Text, args[1].Text)}, nil
+}
+
+func builtinContains(args ...Node) (Node, error) {
+	if err := textArgs(args); err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeBool, Bool: strings.Contains(args[0].Text, args[1].Text)}, nil
+}
+
+func builtinSubstr(args ...Node) (Node, error) {
+	s, err := textArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+
+	start, err := intArg(args, 1)
+	if err != nil {
+		return Node{}, err
+	}
+	if start < 0 {
+		return Node{}, fmt.Errorf("start index %d must not be negative", start)
+	}
+
+	length := int64(len(s))
+	if len(args) > 2 {
+		length, err = intArg(args, 2)
+		if err != nil {
+			return Node{}, err
+		}
+		if length < 0 {
+			return Node{}, fmt.Errorf("length %d must not be negative", length)
+		}
+	}
+
+	var i, lo, hi int64
+	lo, hi = int64(len(s)), int64(len(s))
+	for pos := range s {
+		if i == start {
+			lo = int64(pos)
+		}
+		if i == start+length {
+			hi = int64(pos)
+			break
+		}
+		i++
+	}
+
+	if lo > hi {
+		lo = hi
+	}
+
+	return Node{Type: nodeText, Text: s[lo:hi]}, nil
+}
+
+func builtinIndexOf(args ...Node) (Node, error) {
+	if err := textArgs(args); err != nil {
+		return Node{}, err
+	}
+
+	idx := strings.Index(args[0].Text, args[1].Text)
+	if idx > 0 {
+		idx = utf8.RuneCountInString(args[0].Text[:idx])
+	}
+
+	return Node{Type: nodeInt, Int: int64(idx)}, nil
+}
+
+func builtinReplace(args ...Node) (Node, error) {
+	if err := textArgs(args); err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeText, Text: strings.Replace(args[0].Text, args[1].Text, args[2].Text, -1)}, nil
+}
@@ -0,0 +1,74 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "héllo", rule: `len($) == 5`, pass: true},
+		{in: "日本語", rule: `len($) 3`, pass: true},
+		{in: "", rule: `len("") 0`, pass: true},
+		{in: "Admin", rule: `lower($) "admin" & upper($) "ADMIN"`, pass: true},
+		{in: "ÉCOLE", rule: `lower($) "école"`, pass: true},
+		{in: "  padded \t", rule: `trim($) "padded"`, pass: true},
+		{in: "SKU-123", rule: `startsWith($, "SKU-") & endsWith($, "123")`, pass: true},
+		{in: "SKU-123", rule: `startsWith($, "sku-")`, pass: false},
+		{in: "user@example.com", rule: `contains($, "@") & !contains($, " ")`, pass: true},
+		{in: "héllo wörld", rule: `substr($, 6) "wörld"`, pass: true},
+		{in: "héllo wörld", rule: `substr($, 1, 4) "éllo"`, pass: true},
+		{in: "héllo", rule: `substr($, 3, 100) "lo"`, pass: true},
+		{in: "héllo", rule: `substr($, 10) ""`, pass: true},
+		{in: "héllo", rule: `substr($, 0, 0) ""`, pass: true},
+		{in: "héllo wörld", rule: `indexOf($, "wörld") 6`, pass: true},
+		{in: "héllo", rule: `indexOf($, "x") == -1`, pass: true},
+		{in: "héllo", rule: `indexOf($, "h") 0`, pass: true},
+		{in: "a-b-c", rule: `replace($, "-", "") "abc"`, pass: true},
+		{in: "  Mixed Case ", rule: `lower(trim($)) ~ "^[a-z ]+$" & len(trim($)) (>=3 & <=10)`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestBuiltinErrors(t *testing.T) {
+	cases := []string{
+		`substr($, -1)`,
+		`substr($, 0, -1)`,
+		`len(x)`,
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test)
+		require.NoError(t, err, test)
+
+		_, err = px.Eval("abc")
+		require.Error(t, err, test)
+	}
+
+	for _, test := range []string{
+		`len()`,
+		`startsWith($)`,
+		`substr($, 1, 2, 3)`,
+		`replace($, "a")`,
+		`len(123)`,
+		`lower(1.5)`,
+		`startsWith($, 1)`,
+		`substr($, "1")`,
+		`replace($, "a", null)`,
+	} {
+		_, err := ParseRule(test)
+		require.Error(t, err, test)
+	}
+}
@@ -0,0 +1,300 @@
+package boat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var weekdays = map[string]int64{
+	"mon": 1,
+	"tue": 2,
+	"wed": 3,
+	"thu": 4,
+	"fri": 5,
+	"sat": 6,
+	"sun": 7,
+}
+
+// maxCached bounds the number of zones and schedules cached, as their names
+// may come from the input being evaluated.
+const maxCached = 256
+
+var (
+	locations = cache{vals: make(map[string]interface{})} // map[string]*time.Location
+	schedules = cache{vals: make(map[string]interface{})} // map[string]*schedule
+)
+
+type cache struct {
+	mu   sync.Mutex             // guards vals
+	vals map[string]interface{} // cached values by name
+}
+
+func (c *cache) load(name string, fn func(string) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	val, ok := c.vals[name]
+	c.mu.Unlock()
+	if ok {
+		return val, nil
+	}
+
+	val, err := fn(name)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.vals {
+		if len(c.vals) < maxCached {
+			break
+		}
+		delete(c.vals, k)
+	}
+	c.vals[name] = val
+	return val, nil
+}
+
+func init() {
+	builtins.registerInputFunc("year", 1, 1, calendarFunc(func(t time.Time) int { return t.Year() }))
+	builtins.registerInputFunc("month", 1, 1, calendarFunc(func(t time.Time) int { return int(t.Month()) }))
+	builtins.registerInputFunc("day", 1, 1, calendarFunc(time.Time.Day))
+	builtins.registerInputFunc("yearday", 1, 1, calendarFunc(time.Time.YearDay))
+	builtins.registerInputFunc("hour", 1, 1, calendarFunc(time.Time.Hour))
+	builtins.registerInputFunc("minute", 1, 1, calendarFunc(time.Time.Minute))
+	builtins.registerInputFunc("second", 1, 1, calendarFunc(time.Time.Second))
+	builtins.registerInputFunc("weekday", 1, 1, calendarFunc(isoWeekday))
+	builtins.registerInputFunc("tz", 2, 2, builtinTZ)
+	builtins.registerInputFunc("at", 2, 2, builtinAt)
+
+	times := typeSet(1<<nodeTime | 1<<nodeText)
+	for _, name := range []string{"year", "month", "day", "yearday", "hour", "minute", "second", "weekday"} {
+		builtins.typed(name, 1<<nodeInt, times)
+	}
+	builtins.typed("tz", 1<<nodeTime, times, 1<<nodeText)
+	builtins.typed("at", 1<<nodeBool, times, 1<<nodeText)
+
+	builtins.checked("tz", func(i int, n Node) error {
+		if i == 1 && n.Type == nodeText {
+			_, err := loadLocation(n.Text)
+			return err
+		}
+		return nil
+	})
+	builtins.checked("at", func(i int, n Node) error {
+		if i == 1 && n.Type == nodeText {
+			_, err := loadSchedule(n.Text)
+			return err
+		}
+		return nil
+	})
+}
+
+func timeArg(args []Node, i int) (time.Time, error) {
+	t, ok := timeOf(args[i])
+	if !ok {
+		return t, fmt.Errorf("arg %d must be a time, got %s", i+1, args[i].Type)
+	}
+	return t, nil
+}
+
+func calendarFunc(field func(time.Time) int) Func {
+	return func(args ...Node) (Node, error) {
+		t, err := timeArg(args, 0)
+		if err != nil {
+			return Node{}, err
+		}
+		return Node{Type: nodeInt, Int: int64(field(t))}, nil
+	}
+}
+
+func isoWeekday(t time.Time) int {
+	if wd := t.Weekday(); wd != time.Sunday {
+		return int(wd)
+	}
+	return 7
+}
+
+// loadLocation loads a zone from the system database, which binaries that run
+// without one can embed by importing time/tzdata.
+func loadLocation(name string) (*time.Location, error) {
+	loc, err := locations.load(name, func(name string) (interface{}, error) {
+		return time.LoadLocation(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loc.(*time.Location), nil
+}
+
+func builtinTZ(args ...Node) (Node, error) {
+	t, err := timeArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	name, err := textArg(args, 1)
+	if err != nil {
+		return Node{}, err
+	}
+	loc, err := loadLocation(name)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeTime, Time: t.In(loc)}, nil
+}
+
+func builtinAt(args ...Node) (Node, error) {
+	t, err := timeArg(args, 0)
+	if err != nil {
+		return Node{}, err
+	}
+	spec, err := textArg(args, 1)
+	if err != nil {
+		return Node{}, err
+	}
+	s, err := loadSchedule(spec)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeBool, Bool: s.matches(t)}, nil
+}
+
+type schedule struct {
+	minute uint64 // minutes 0-59
+	hour   uint64 // hours 0-23
+	dom    uint64 // days of month 1-31
+	month  uint64 // months 1-12
+	dow    uint64 // days of week 0-6, sunday is 0
+	anyDom bool   // day of month field starts with '*'?
+	anyDow bool   // day of week field starts with '*'?
+}
+
+var cronFields = [...]struct {
+	name  string   // field name
+	min   int      // min value
+	max   int      // max value
+	names []string // value names, indexed from min
+}{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+func loadSchedule(spec string) (*schedule, error) {
+	s, err := schedules.load(spec, func(spec string) (interface{}, error) {
+		return parseSchedule(spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*schedule), nil
+}
+
+func parseSchedule(spec string) (*schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression must have %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	var bits [len(cronFields)]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field %q: %w", cronFields[i].name, field, err)
+		}
+		bits[i] = b
+	}
+
+	s := &schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDom: fields[2][0] == '*',
+		anyDow: fields[4][0] == '*',
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+func parseCronField(field string, i int) (uint64, error) {
+	f := cronFields[i]
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if j := strings.IndexByte(part, '/'); j >= 0 {
+			n, err := strconv.Atoi(part[j+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[j+1:])
+			}
+			rng, step = part[:j], n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			var err error
+			if j := strings.IndexByte(rng, '-'); j >= 0 {
+				if lo, err = cronValue(rng[:j], i); err != nil {
+					return 0, err
+				}
+				if hi, err = cronValue(rng[j+1:], i); err != nil {
+					return 0, err
+				}
+			} else {
+				if lo, err = cronValue(rng, i); err != nil {
+					return 0, err
+				}
+				if step == 1 {
+					hi = lo
+				}
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", rng, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func cronValue(val string, i int) (int, error) {
+	f := cronFields[i]
+	for j, name := range f.names {
+		if strings.EqualFold(val, name) {
+			return f.min + j, nil
+		}
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", val)
+	}
+	return n, nil
+}
+
+func (s *schedule) matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
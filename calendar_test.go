@@ -0,0 +1,112 @@
+package boat
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCalendarRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "2024-03-15T10:30:00Z", rule: `weekday in [mon..fri] & hour in 9..17`, pass: true},
+		{in: "2024-03-16T10:30:00Z", rule: `weekday in [mon..fri] & hour in 9..17`, pass: false},
+		{in: "2024-03-15T18:00:00Z", rule: `weekday in [mon..fri] & hour in 9..17`, pass: false},
+		{in: "2024-03-17T10:30:00Z", rule: `weekday sat..sun`, pass: true},
+		{in: "2024-03-17T10:30:00Z", rule: `weekday == sun & weekday 7`, pass: true},
+		{in: "2024-03-17T10:30:00Z", rule: `weekday !(mon | tue) & weekday(now) in [mon..sun]`, pass: true},
+		{in: "2024-03-15T10:30:45Z", rule: `year 2024 & month 3 & day 15 & yearday 75 & minute 30 & second 45`, pass: true},
+		{in: "2024-03-15T10:30:00Z", rule: `at("0 9-17 * * 1-5")`, pass: false},
+		{in: "2024-03-15T10:00:00Z", rule: `at("0 9-17 * * 1-5")`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `at("*/15 9-17 * * mon-fri")`, pass: true},
+		{in: "2024-03-16T10:00:00Z", rule: `at("*/15 9-17 * * mon-fri")`, pass: false},
+		{in: "2024-03-17T10:00:00Z", rule: `at("* * * * 0") & at("* * * * 7") & at("* * * * sun")`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `at("* * 1,15 mar *")`, pass: true},
+		{in: "2024-03-14T10:00:00Z", rule: `at("* * 1 * fri")`, pass: false},
+		{in: "2024-03-15T10:00:00Z", rule: `at("* * 1 * fri")`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `at($, "0 10 * * *")`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `hour($) 10 & hour($ + 1h) 11`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `tz("Europe/Berlin") (hour 11 & at("0 11 * * *"))`, pass: true},
+		{in: "2024-07-15T10:00:00Z", rule: `tz("Europe/Berlin") hour 12`, pass: true},
+		{in: "2024-03-15T23:30:00-05:00", rule: `day 15 & tz("UTC") (day 16 & weekday sat)`, pass: true},
+		{in: "2024-03-15T10:00:00Z", rule: `tz($, "America/New_York") (hour 6)`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestCalendarEnv(t *testing.T) {
+	px, err := ParseRule(`created (weekday in [mon..fri] & hour in 9..17) & hour(updated) 8 & $.hour 3`)
+	require.NoError(t, err)
+
+	pass, err := px.EvalEnv("", Env{
+		"created": TimeNode(time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)),
+		"updated": TextNode("2024-03-15T08:00:00Z"),
+		"hour":    IntNode(3),
+	})
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	px, err = ParseRule(`sun > 0 & $.mon == 1 & sat (2 | weekday(fri) == 5)`)
+	require.NoError(t, err)
+
+	pass, err = px.EvalEnv("", Env{"sun": IntNode(1), "mon": IntNode(1), "sat": IntNode(2), "fri": TextNode("2024-03-15")})
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+func TestCalendarErrors(t *testing.T) {
+	cases := []string{
+		`at("0 9-17 * *")`,
+		`at("60 * * * *")`,
+		`at("* 17-9 * * *")`,
+		`at("*/0 * * * *")`,
+		`at("* * * foo *")`,
+		`tz("Mars/Olympus_Mons") hour 1`,
+		`tz($, "Nowhere/City") hour 1`,
+		`at("bad")`,
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test)
+
+		var serr *SyntaxError
+		require.True(t, errors.As(err, &serr), test)
+	}
+
+	px, err := ParseRule(`at(x)`)
+	require.NoError(t, err)
+
+	_, err = px.EvalEnv("2024-03-15T10:00:00Z", Env{"x": TextNode("bad")})
+	require.Error(t, err)
+
+	px, err = ParseRule(`hour 1`)
+	require.NoError(t, err)
+
+	_, err = px.Eval("abc")
+	require.Error(t, err)
+}
+
+func TestCalendarCacheBound(t *testing.T) {
+	px, err := ParseRule(`at($.spec)`)
+	require.NoError(t, err)
+
+	for i := 0; i < 2*maxCached; i++ {
+		_, err := px.EvalEnv("2024-03-15T10:00:00Z", Env{"spec": TextNode(fmt.Sprintf("%d %d * * *", i%60, i/60))})
+		require.NoError(t, err)
+	}
+
+	require.LessOrEqual(t, len(schedules.vals), maxCached)
+}
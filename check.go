@@ -0,0 +1,355 @@
+package boat
+
+import (
+	"fmt"
+	"strings"
+)
+
+type typeSet uint16
+
+const (
+	numberTypes  = 1<<nodeInt | 1<<nodeFloat
+	orderedTypes = numberTypes | 1<<nodeTime | 1<<nodeDuration
+	anyType      = typeSet(1<<(nodeDuration+1) - 1)
+)
+
+func (s typeSet) has(t NodeType) bool {
+	return s&(1<<t) != 0
+}
+
+func (s typeSet) String() string {
+	var names []string
+	for t := nodeBool; t <= nodeDuration; t++ {
+		if s.has(t) {
+			names = append(names, t.String())
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+type InputKind uint8
+
+const (
+	InputNumber InputKind = 1 << iota
+	InputText
+	InputBool
+	InputTime
+	InputNull
+
+	InputAny = InputNumber | InputText | InputBool | InputTime | InputNull
+)
+
+var inputKinds = [...]struct {
+	kind  InputKind // input kind
+	name  string    // kind name
+	types typeSet   // decoded node types
+}{
+	{kind: InputNumber, name: "number", types: numberTypes},
+	{kind: InputText, name: "text", types: 1 << nodeText},
+	{kind: InputBool, name: "bool", types: 1 << nodeBool},
+	{kind: InputTime, name: "time", types: 1 << nodeTime},
+	{kind: InputNull, name: "null", types: 1 << nodeNull},
+}
+
+func (k InputKind) String() string {
+	var names []string
+	for _, i := range inputKinds {
+		if k&i.kind != 0 {
+			names = append(names, i.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+type checker struct {
+	rule string // rule
+	dry  bool   // infer types without recording them on exprs?
+}
+
+func check(rule string, root *Expr) error {
+	c := checker{rule: rule}
+	_, err := c.infer(root, anyType)
+	return err
+}
+
+// accepts reports the input kinds a checked and folded rule may pass.
+func accepts(rule string, root *Expr) InputKind {
+	c := checker{rule: rule, dry: true}
+
+	var kinds InputKind
+	for _, i := range inputKinds {
+		if _, err := c.infer(root, i.types); err == nil && c.may(root, i.types) {
+			kinds |= i.kind
+		}
+	}
+
+	return kinds
+}
+
+func (c *checker) errorf(x *Expr, format string, args ...interface{}) error {
+	start, end := span(x)
+	return syntaxError(c.rule, start, end, fmt.Errorf(format, args...))
+}
+
+func span(x *Expr) (int, int) {
+	start, end := x.Start, x.End
+	if x.Type == tokText {
+		start, end = start-1, end+1
+	}
+	for _, arg := range x.Args {
+		s, e := span(arg)
+		if s < start {
+			start = s
+		}
+		if e > end {
+			end = e
+		}
+	}
+	return start, end
+}
+
+func (c *checker) infer(x *Expr, in typeSet) (typeSet, error) {
+	var args []typeSet
+
+	if x.Type == tokSubject {
+		subject, err := c.infer(x.Args[0], in)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := c.infer(x.Args[1], subject); err != nil {
+			return 0, err
+		}
+	} else {
+		args = make([]typeSet, len(x.Args))
+		for i, arg := range x.Args {
+			t, err := c.infer(arg, in)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = t
+		}
+	}
+
+	var t typeSet
+
+	switch {
+	case x.literal():
+		t = 1 << x.Val.Type
+	case x.Type == tokIdent || x.Type == tokPointer:
+		t = anyType
+	case x.Type == tokCall:
+		for i, arg := range args {
+			if arg&x.sig.arg(i) == 0 {
+				return 0, c.errorf(x.Args[i], "cannot pass %s as arg %d of %q", arg, i+1, x.Val.Text)
+			}
+		}
+		if t = x.sig.out; t == 0 {
+			t = anyType
+		}
+	case x.Type == tokInput:
+		t = in
+	case x.Type == tokNow:
+		t = 1 << nodeTime
+	case x.Type == tokNegate:
+		if t = args[0] & (numberTypes | 1<<nodeDuration); t == 0 {
+			return 0, c.errorf(x, "cannot apply '-' to %s", args[0])
+		}
+	case x.Type == tokBitNot:
+		if !args[0].has(nodeInt) {
+			return 0, c.errorf(x, "cannot apply '~~~' to %s", args[0])
+		}
+		t = 1 << nodeInt
+	case isBinaryOp(x.Type) && x.Type != tokAND && x.Type != tokOR:
+		for a := nodeBool; a <= nodeDuration; a++ {
+			for b := nodeBool; b <= nodeDuration; b++ {
+				if args[0].has(a) && args[1].has(b) {
+					t |= binaryType(x.Type, a, b)
+				}
+			}
+		}
+		if t == 0 {
+			return 0, c.errorf(x, "cannot apply '%s' to %s and %s", x.Type, args[0], args[1])
+		}
+	case x.Type == tokGT, x.Type == tokGTE, x.Type == tokLT, x.Type == tokLTE:
+		if args[0]&orderedTypes == 0 {
+			return 0, c.errorf(x, "'%s' not paired with an ordered value, got %s", x.Type, args[0])
+		}
+		t = 1 << nodeBool
+	case x.Type == tokIn, x.Type == tokNotIn:
+		if args[0]&(1<<nodeList|1<<nodeRange) == 0 {
+			return 0, c.errorf(x, "'%s' not paired with a list or range, got %s", x.Type, args[0])
+		}
+		t = 1 << nodeBool
+	default:
+		t = 1 << nodeBool
+	}
+
+	if !c.dry {
+		x.types = t
+	}
+
+	return t, nil
+}
+
+func binaryType(op TokenType, a, b NodeType) typeSet {
+	ints := a == nodeInt && b == nodeInt
+	nums := numberTypes&(1<<a) != 0 && numberTypes&(1<<b) != 0
+
+	switch op {
+	case tokPlus:
+		switch {
+		case ints:
+			return 1 << nodeInt
+		case nums:
+			return 1 << nodeFloat
+		case a == nodeText && b == nodeText:
+			return 1 << nodeText
+		case a == nodeTime && b == nodeDuration, a == nodeDuration && b == nodeTime:
+			return 1 << nodeTime
+		case a == nodeDuration && b == nodeDuration:
+			return 1 << nodeDuration
+		}
+	case tokMinus:
+		switch {
+		case ints:
+			return 1 << nodeInt
+		case nums:
+			return 1 << nodeFloat
+		case a == nodeTime && b == nodeDuration:
+			return 1 << nodeTime
+		case a == nodeTime && b == nodeTime, a == nodeDuration && b == nodeDuration:
+			return 1 << nodeDuration
+		}
+	case tokMultiply:
+		switch {
+		case ints:
+			return 1 << nodeInt
+		case nums:
+			return 1 << nodeFloat
+		case a == nodeText && b == nodeInt:
+			return 1 << nodeText
+		}
+	case tokDivide, tokModulo:
+		switch {
+		case ints:
+			return 1 << nodeInt
+		case nums:
+			return 1 << nodeFloat
+		}
+	case tokPower:
+		switch {
+		case ints:
+			return numberTypes
+		case nums:
+			return 1 << nodeFloat
+		}
+	case tokShiftLeft, tokShiftRight, tokBitAnd, tokBitOr, tokBitXor:
+		if ints {
+			return 1 << nodeInt
+		}
+	case tokRange:
+		if ordered(a, b) {
+			return 1 << nodeRange
+		}
+	}
+	return 0
+}
+
+func ordered(a, b NodeType) bool {
+	switch {
+	case numberTypes&(1<<a) != 0 && numberTypes&(1<<b) != 0:
+		return true
+	case a == nodeTime || b == nodeTime:
+		return a == b || a == nodeText || b == nodeText
+	default:
+		return a == nodeDuration && b == nodeDuration
+	}
+}
+
+func equal(a, b NodeType) bool {
+	return a == b || ordered(a, b)
+}
+
+func equalable(in typeSet, n Node) bool {
+	if n.Type == nodeText && !isTime(n.Text) {
+		return in.has(nodeText)
+	}
+	return pairs(in, 1<<n.Type, equal)
+}
+
+func pairs(in, t typeSet, pred func(a, b NodeType) bool) bool {
+	for a := nodeBool; a <= nodeDuration; a++ {
+		for b := nodeBool; b <= nodeDuration; b++ {
+			if in.has(a) && t.has(b) && pred(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *checker) may(x *Expr, in typeSet) bool {
+	switch x.Type {
+	case tokAND:
+		return c.may(x.Args[0], in) && c.may(x.Args[1], in)
+	case tokOR:
+		return c.may(x.Args[0], in) || c.may(x.Args[1], in)
+	case tokSubject:
+		subject, err := c.infer(x.Args[0], in)
+		return err == nil && c.may(x.Args[1], subject)
+	case tokRequired:
+		return in&^(1<<nodeNull) != 0 && (len(x.Args) == 0 || c.may(x.Args[0], in&^(1<<nodeNull)))
+	case tokOptional:
+		return in.has(nodeNull) || len(x.Args) == 0 || c.may(x.Args[0], in&^(1<<nodeNull))
+	case tokBang, tokNEQ, tokNotMatch, tokNotIn:
+		return true
+	case tokMatch:
+		return in.has(nodeText)
+	case tokEQ:
+		if x.Args[0].literal() {
+			return equalable(in, x.Args[0].Val)
+		}
+		t, err := c.infer(x.Args[0], in)
+		return err == nil && pairs(in, t, equal)
+	case tokGT, tokGTE, tokLT, tokLTE:
+		t, err := c.infer(x.Args[0], in)
+		return err == nil && pairs(in, t, ordered)
+	case tokIn:
+		return c.member(x.Args[0], in)
+	}
+
+	t, err := c.infer(x, in)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case !x.literal():
+		return t.has(nodeBool) || t&(1<<nodeList|1<<nodeRange) != 0 || pairs(in, t, equal)
+	case x.Val.Type == nodeBool:
+		return x.Val.Bool
+	case x.Val.Type == nodeList, x.Val.Type == nodeRange:
+		return c.member(x, in)
+	default:
+		return equalable(in, x.Val)
+	}
+}
+
+func (c *checker) member(x *Expr, in typeSet) bool {
+	switch {
+	case !x.literal():
+		return true
+	case x.Val.Type == nodeRange:
+		return pairs(in, 1<<x.Val.Range.Lo.Type|1<<x.Val.Range.Hi.Type, ordered)
+	case x.Val.Type == nodeList:
+		for _, item := range x.Val.List.Items {
+			if item.Type == nodeRange && pairs(in, 1<<item.Range.Lo.Type, ordered) || equalable(in, item) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTypeErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  string
+	}{
+		{rule: `123 + "hello world"`, err: `line 1, column 1: cannot apply '+' to int and text`},
+		{rule: `"test" - 3`, err: `line 1, column 1: cannot apply '-' to text and int`},
+		{rule: `"test" / 3`, err: `line 1, column 1: cannot apply '/' to text and int`},
+		{rule: `>="test"`, err: `line 1, column 1: '>=' not paired with an ordered value, got text`},
+		{rule: `age > 1 & (5 + true) 1`, err: `line 1, column 12: cannot apply '+' to int and bool`},
+		{rule: `-"a" == 1`, err: `line 1, column 1: cannot apply '-' to text`},
+		{rule: `~~~1.5 == 1`, err: `line 1, column 1: cannot apply '~~~' to float`},
+		{rule: `in "abc"`, err: `line 1, column 1: 'in' not paired with a list or range, got text`},
+		{rule: `now + 1`, err: `line 1, column 1: cannot apply '+' to time and int`},
+		{rule: `now..5`, err: `line 1, column 1: cannot apply '..' to time and int`},
+		{rule: `$ &&& 1.5 == 1`, err: `line 1, column 1: cannot apply '&&&' to bool|int|float|text|null|list|range|time|duration and float`},
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	cases := []struct {
+		rule    string
+		accepts InputKind
+	}{
+		{rule: `>= 18`, accepts: InputNumber},
+		{rule: `>= 1 & <= 400 | "US"`, accepts: InputNumber | InputText},
+		{rule: `~ "^[a-z]+$"`, accepts: InputText},
+		{rule: `len($) > 3`, accepts: InputText},
+		{rule: `len($) >= 3`, accepts: InputText},
+		{rule: `"he" * 3`, accepts: InputText},
+		{rule: `123 + 456`, accepts: InputNumber},
+		{rule: `year >= 2000`, accepts: InputText | InputTime},
+		{rule: `$ - 1`, accepts: InputNumber},
+		{rule: `$ + 1 == 2`, accepts: InputNumber},
+		{rule: `$ * 2 == "abab"`, accepts: InputText},
+		{rule: `in ["a", 1]`, accepts: InputNumber | InputText},
+		{rule: `1..5`, accepts: InputNumber},
+		{rule: `> now - 18y`, accepts: InputText | InputTime},
+		{rule: `age >= 18`, accepts: InputAny},
+		{rule: `required`, accepts: InputAny &^ InputNull},
+		{rule: `optional >= 5`, accepts: InputNumber | InputNull},
+		{rule: `true`, accepts: InputAny},
+		{rule: `false`, accepts: 0},
+		{rule: `!(>= 5)`, accepts: InputAny},
+		{rule: `>= 5 & ~ "a"`, accepts: 0},
+	}
+
+	var types func(x *Expr) []typeSet
+	types = func(x *Expr) []typeSet {
+		ts := []typeSet{x.types}
+		for _, arg := range x.Args {
+			ts = append(ts, types(arg)...)
+		}
+		return ts
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+		require.Equal(t, test.accepts, px.Accepts(), test.rule)
+
+		checked := types(px.root)
+		require.Equal(t, test.accepts, accepts(px.rule, px.root), test.rule)
+		require.Equal(t, checked, types(px.root), test.rule)
+	}
+
+	require.Equal(t, "number|text", (InputNumber | InputText).String())
+	require.Equal(t, "none", InputKind(0).String())
+}
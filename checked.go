@@ -0,0 +1,119 @@
+package boat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// longest text that may be produced by repeating text with '*'
+const maxRepeatLen = 1 << 20
+
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrOverflow       = errors.New("integer overflow")
+	ErrRepeatCount    = errors.New("invalid repeat count")
+	ErrShiftCount     = errors.New("invalid shift count")
+	ErrPanic          = errors.New("panic while evaluating rule")
+)
+
+type OverflowPolicy int
+
+const (
+	OverflowError    OverflowPolicy = iota // fail with ErrOverflow
+	OverflowSaturate                       // clamp to the int64 range
+	OverflowWrap                           // wrap around using two's complement
+)
+
+func WithOverflow(policy OverflowPolicy) Option {
+	return func(r *Rule) {
+		r.overflow = policy
+	}
+}
+
+func (e *Rule) checked(op TokenType, z, sat int64, overflow bool) (int64, error) {
+	if !overflow {
+		return z, nil
+	}
+	switch e.overflow {
+	case OverflowSaturate:
+		return sat, nil
+	case OverflowWrap:
+		return z, nil
+	}
+	return 0, fmt.Errorf(`'%s': %w`, op, ErrOverflow)
+}
+
+func (e *Rule) addInt(x, y int64) (int64, error) {
+	z := x + y
+	return e.checked(tokPlus, z, limit(x >= 0), (x^z)&(y^z) < 0)
+}
+
+func (e *Rule) subInt(x, y int64) (int64, error) {
+	z := x - y
+	return e.checked(tokMinus, z, limit(x >= 0), (x^y)&(x^z) < 0)
+}
+
+func (e *Rule) mulInt(x, y int64) (int64, error) {
+	return e.checked(tokMultiply, x*y, limit((x < 0) == (y < 0)), mulOverflows(x, y))
+}
+
+func (e *Rule) divInt(x, y int64) (int64, error) {
+	if y == 0 {
+		return 0, fmt.Errorf(`'/': %w`, ErrDivisionByZero)
+	}
+	return e.checked(tokDivide, x/y, math.MaxInt64, x == math.MinInt64 && y == -1)
+}
+
+func (e *Rule) negInt(x int64) (int64, error) {
+	return e.checked(tokNegate, -x, math.MaxInt64, x == math.MinInt64)
+}
+
+func (e *Rule) powInt(x, y int64) (int64, error) {
+	var overflow bool
+	z, b := int64(1), x
+	for n := y; n > 0; {
+		if n&1 == 1 {
+			overflow = overflow || mulOverflows(z, b)
+			z *= b
+		}
+		if n >>= 1; n > 0 {
+			overflow = overflow || mulOverflows(b, b)
+			b *= b
+		}
+	}
+	return e.checked(tokPower, z, limit(x >= 0 || y&1 == 0), overflow)
+}
+
+func (e *Rule) shlInt(x, y int64) (int64, error) {
+	if y < 0 {
+		return 0, fmt.Errorf(`'<<': %w %d`, ErrShiftCount, y)
+	}
+	if y >= 64 {
+		return e.checked(tokShiftLeft, 0, limit(x >= 0), x != 0)
+	}
+	z := x << uint64(y)
+	return e.checked(tokShiftLeft, z, limit(x >= 0), z>>uint64(y) != x)
+}
+
+func mulOverflows(x, y int64) bool {
+	if x == 0 || y == 0 {
+		return false
+	}
+	return (x*y)/y != x || x == -1 && y == math.MinInt64 || y == -1 && x == math.MinInt64
+}
+
+func repeat(s string, n int64) (string, error) {
+	if n < 0 || s != "" && n > maxRepeatLen/int64(len(s)) {
+		return "", fmt.Errorf(`'*': %w %d`, ErrRepeatCount, n)
+	}
+	return strings.Repeat(s, int(n)), nil
+}
+
+func limit(positive bool) int64 {
+	if positive {
+		return math.MaxInt64
+	}
+	return math.MinInt64
+}
@@ -0,0 +1,89 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestCheckedErrors(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		err  error
+	}{
+		{in: "1", rule: `$ / 0 == 1`, err: ErrDivisionByZero},
+		{in: "1", rule: `$ % 0 == 1`, err: ErrDivisionByZero},
+		{in: "1", rule: `1 / ($ - 1) == 1`, err: ErrDivisionByZero},
+		{in: "9223372036854775807", rule: `$ + 1 > 0`, err: ErrOverflow},
+		{in: "-9223372036854775807", rule: `$ - 2 < 0`, err: ErrOverflow},
+		{in: "9223372036854775807", rule: `$ * 2 > 0`, err: ErrOverflow},
+		{in: "-9223372036854775807", rule: `($ - 1) / -1 > 0`, err: ErrOverflow},
+		{in: "-9223372036854775807", rule: `-($ - 1) > 0`, err: ErrOverflow},
+		{in: "2", rule: `$ ** 63 > 0`, err: ErrOverflow},
+		{in: "9223372036854775807", rule: `$ << 1 > 0`, err: ErrOverflow},
+		{in: "1", rule: `$ << 64 > 0`, err: ErrOverflow},
+		{in: "-2", rule: `$ << 63 < 0`, err: ErrOverflow},
+		{in: "1", rule: `1 << -$ > 0`, err: ErrShiftCount},
+		{in: "1", rule: `1 >> -$ > 0`, err: ErrShiftCount},
+		{in: "a", rule: `$ * -1 == ""`, err: ErrRepeatCount},
+		{in: "a", rule: `$ * 9223372036854775807 == ""`, err: ErrRepeatCount},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		_, err = px.Eval(test.in)
+		require.Error(t, err, test.rule)
+		require.True(t, errors.Is(err, test.err), test.rule)
+	}
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	cases := []struct {
+		in     string
+		rule   string
+		policy OverflowPolicy
+		out    int64
+	}{
+		{in: "9223372036854775807", rule: `$ + 1`, policy: OverflowSaturate, out: math.MaxInt64},
+		{in: "-9223372036854775807", rule: `$ - 2`, policy: OverflowSaturate, out: math.MinInt64},
+		{in: "9223372036854775807", rule: `$ * -2`, policy: OverflowSaturate, out: math.MinInt64},
+		{in: "-3", rule: `$ ** 41`, policy: OverflowSaturate, out: math.MinInt64},
+		{in: "-3", rule: `$ ** 40`, policy: OverflowSaturate, out: math.MaxInt64},
+		{in: "9223372036854775807", rule: `$ + 1`, policy: OverflowWrap, out: math.MinInt64},
+		{in: "2", rule: `$ ** 62`, policy: OverflowError, out: 1 << 62},
+		{in: "9223372036854775807", rule: `$ << 1`, policy: OverflowSaturate, out: math.MaxInt64},
+		{in: "-9223372036854775807", rule: `$ << 1`, policy: OverflowSaturate, out: math.MinInt64},
+		{in: "1", rule: `$ << 64`, policy: OverflowSaturate, out: math.MaxInt64},
+		{in: "0", rule: `$ << 64`, policy: OverflowSaturate, out: 0},
+		{in: "9223372036854775807", rule: `$ << 1`, policy: OverflowWrap, out: -2},
+		{in: "1", rule: `$ << 64`, policy: OverflowWrap, out: 0},
+		{in: "-1", rule: `$ << 63`, policy: OverflowError, out: math.MinInt64},
+		{in: "1", rule: `$ << 62`, policy: OverflowError, out: 1 << 62},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule+` == x`, WithOverflow(test.policy))
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.EvalEnv(test.in, Env{"x": IntNode(test.out)})
+		require.NoError(t, err, test.rule)
+		require.True(t, pass, test.rule)
+	}
+}
+
+func TestEvalRecoversPanics(t *testing.T) {
+	reg := NewRegistry(nil)
+	reg.RegisterFunc("boom", func(args ...Node) (Node, error) {
+		panic("boom")
+	})
+
+	px, err := ParseRule(`boom()`, WithRegistry(reg))
+	require.NoError(t, err)
+
+	_, err = px.Eval("1")
+	require.True(t, errors.Is(err, ErrPanic))
+}
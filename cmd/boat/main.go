@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/lithdew/boat"
+	"io/ioutil"
+	"os"
+)
+
+const usage = `usage: boat fmt [-l] [-w] [path ...]
+
+Formats rules read from the given files, or from stdin if no files are given.
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "fmt" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write result to the source file instead of stdout")
+	flags.Parse(os.Args[2:])
+
+	if flags.NArg() == 0 {
+		if err := format("<stdin>", os.Stdin, false, false); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	code := 0
+	for _, path := range flags.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		err = format(path, f, *list, *write)
+		f.Close()
+		if err != nil {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+func format(path string, f *os.File, list, write bool) error {
+	src, err := ioutil.ReadAll(f)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return err
+	}
+
+	res, err := boat.Format(string(src))
+	if err != nil {
+		report(path, err)
+		return err
+	}
+	out := []byte(res + "\n")
+
+	if !list && !write {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	if bytes.Equal(src, out) {
+		return nil
+	}
+	if list {
+		fmt.Println(path)
+	}
+	if write {
+		if err := ioutil.WriteFile(path, out, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func report(path string, err error) {
+	var errs boat.ErrorList
+	if !errors.As(err, &errs) {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+		return
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n%s\n", path, err.Line, err.Column, err.Err, err.Snippet)
+	}
+}
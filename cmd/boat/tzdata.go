@@ -0,0 +1,8 @@
+//go:build go1.15
+// +build go1.15
+
+package main
+
+// embed the zone database so tz() works on systems without one; the boat
+// package leaves this choice to the binaries that use it
+import _ "time/tzdata"
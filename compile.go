@@ -0,0 +1,218 @@
+package boat
+
+import (
+	"fmt"
+	"strings"
+)
+
+type opcode uint8
+
+const (
+	opConst       opcode = iota // push consts[arg]
+	opCompare                   // compare the input against the top of the stack using op arg
+	opArith                     // apply arithmetic op arg to the top of the stack
+	opTruth                     // evaluate the top of the stack against the input
+	opJumpIfFalse               // jump to arg if the top of the stack is false, otherwise pop it
+	opJumpIfTrue                // jump to arg if the top of the stack is true, otherwise pop it
+	opInput                     // push the input
+	opLoad                      // push the value of the identifier named consts[arg]
+	opScope                     // pop the top of the stack and make it the input
+	opUnscope                   // restore the previous input
+	opNot                       // negate the boolean on top of the stack
+	opRequired                  // if the input is null, push false and jump to arg
+	opOptional                  // if the input is null, push true and jump to arg
+	opCall                      // call calls[arg] with args popped off the stack
+	opNow                       // push the current time
+)
+
+var opStr = [...]string{
+	opConst:       "CONST",
+	opCompare:     "COMPARE",
+	opArith:       "ARITH",
+	opTruth:       "TRUTH",
+	opJumpIfFalse: "JUMPF",
+	opJumpIfTrue:  "JUMPT",
+	opInput:       "INPUT",
+	opLoad:        "LOAD",
+	opScope:       "SCOPE",
+	opUnscope:     "UNSCOPE",
+	opNot:         "NOT",
+	opRequired:    "REQUIRED",
+	opOptional:    "OPTIONAL",
+	opCall:        "CALL",
+	opNow:         "NOW",
+}
+
+func (o opcode) String() string {
+	return opStr[o]
+}
+
+type instr struct {
+	op  opcode // opcode
+	arg int    // const index, token type, or jump target
+}
+
+type call struct {
+	name string // func name
+	fn   Func   // func
+	argc int    // number of args
+}
+
+type compiler struct {
+	code   []instr // program
+	exprs  []*Expr // source expr of each instruction
+	consts []Node  // constant pool
+	calls  []call  // called funcs
+	depth  int     // current stack depth
+	max    int     // max stack depth
+	scope  int     // current scope depth
+	scopes int     // max scope depth
+}
+
+func compile(root *Expr) *compiler {
+	var c compiler
+	c.truth(root)
+	return &c
+}
+
+func (c *compiler) emit(x *Expr, op opcode, arg int) int {
+	c.code = append(c.code, instr{op: op, arg: arg})
+	c.exprs = append(c.exprs, x)
+	return len(c.code) - 1
+}
+
+func (c *compiler) push(n int) {
+	c.depth += n
+	if c.depth > c.max {
+		c.max = c.depth
+	}
+}
+
+func (c *compiler) constant(n Node) int {
+	c.consts = append(c.consts, n)
+	return len(c.consts) - 1
+}
+
+func (c *compiler) expr(x *Expr) {
+	if x.literal() {
+		c.emit(x, opConst, c.constant(x.Val))
+		c.push(1)
+		return
+	}
+
+	switch x.Type {
+	case tokIdent, tokPointer:
+		c.emit(x, opLoad, c.constant(x.Val))
+		c.push(1)
+		return
+	case tokInput:
+		c.emit(x, opInput, 0)
+		c.push(1)
+		return
+	case tokNow:
+		c.emit(x, opNow, 0)
+		c.push(1)
+		return
+	case tokSubject:
+		c.expr(x.Args[0])
+		c.emit(x, opScope, 0)
+		c.push(-1)
+
+		c.scope++
+		if c.scope > c.scopes {
+			c.scopes = c.scope
+		}
+		c.truth(x.Args[1])
+		c.scope--
+
+		c.emit(x, opUnscope, 0)
+		return
+	case tokCall:
+		for _, arg := range x.Args {
+			c.expr(arg)
+		}
+		c.calls = append(c.calls, call{name: x.Val.Text, fn: x.fn, argc: len(x.Args)})
+		c.emit(x, opCall, len(c.calls)-1)
+		c.push(1 - len(x.Args))
+		return
+	case tokBang:
+		c.truth(x.Args[0])
+		c.emit(x, opNot, 0)
+		return
+	case tokRequired, tokOptional:
+		op := opRequired
+		if x.Type == tokOptional {
+			op = opOptional
+		}
+
+		jmp := c.emit(x, op, 0)
+
+		if len(x.Args) == 0 {
+			c.emit(x, opConst, c.constant(Node{Type: nodeBool, Bool: true}))
+			c.push(1)
+		} else {
+			c.truth(x.Args[0])
+		}
+
+		c.code[jmp].arg = len(c.code)
+		return
+	case tokAND, tokOR:
+		op := opJumpIfFalse
+		if x.Type == tokOR {
+			op = opJumpIfTrue
+		}
+
+		c.truth(x.Args[0])
+		jmp := c.emit(x, op, 0)
+		c.push(-1)
+
+		c.truth(x.Args[1])
+
+		c.code[jmp].arg = len(c.code)
+		return
+	}
+
+	for _, arg := range x.Args {
+		c.expr(arg)
+	}
+
+	if isCompareOp(x.Type) {
+		c.emit(x, opCompare, int(x.Type))
+	} else {
+		c.emit(x, opArith, int(x.Type))
+	}
+
+	c.push(1 - len(x.Args))
+}
+
+func (c *compiler) truth(x *Expr) {
+	c.expr(x)
+	switch {
+	case x.Type == tokAND, x.Type == tokOR, x.Type == tokBang, x.Type == tokSubject, isCompareOp(x.Type):
+	case x.Type == tokRequired, x.Type == tokOptional, x.literal() && x.Val.Type == nodeBool:
+	default:
+		c.emit(x, opTruth, 0)
+	}
+}
+
+func (e *Rule) Disassemble() string {
+	var b strings.Builder
+	for pc, ins := range e.code {
+		line := fmt.Sprintf("%04d %-8s", pc, ins.op)
+		switch ins.op {
+		case opConst:
+			line += fmt.Sprintf("%d (%s %s)", ins.arg, e.consts[ins.arg].Type, e.consts[ins.arg])
+		case opLoad:
+			line += fmt.Sprintf("%d (%s)", ins.arg, e.consts[ins.arg].Text)
+		case opCall:
+			line += fmt.Sprintf("%d (%s/%d)", ins.arg, e.calls[ins.arg].name, e.calls[ins.arg].argc)
+		case opCompare, opArith:
+			line += TokenType(ins.arg).String()
+		case opJumpIfFalse, opJumpIfTrue, opRequired, opOptional:
+			line += fmt.Sprintf("%04d", ins.arg)
+		}
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
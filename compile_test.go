@@ -0,0 +1,24 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	px, err := ParseRule(`>=1 & <=400 | "hello " + name`)
+	require.NoError(t, err)
+
+	expected := `0000 CONST   0 (int 1)
+0001 COMPARE >=
+0002 JUMPF   0005
+0003 CONST   1 (int 400)
+0004 COMPARE <=
+0005 JUMPT   0010
+0006 CONST   2 (text "hello ")
+0007 LOAD    3 (name)
+0008 ARITH   +
+0009 TRUTH
+`
+	require.Equal(t, expected, px.Disassemble())
+}
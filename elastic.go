@@ -0,0 +1,237 @@
+package boat
+
+import (
+	"encoding/json"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var elasticOps = [...]string{
+	tokGT:  "gt",
+	tokGTE: "gte",
+	tokLT:  "lt",
+	tokLTE: "lte",
+}
+
+// Elasticsearch translates the rule into an Elasticsearch query over field.
+func (e *Rule) Elasticsearch(field string) ([]byte, error) {
+	return e.query(field, elastic{})
+}
+
+type elastic struct{}
+
+func (elastic) String() string {
+	return "elasticsearch"
+}
+
+func (elastic) all(match bool) object {
+	if match {
+		return object{"match_all": object{}}
+	}
+	return object{"match_none": object{}}
+}
+
+func (el elastic) and(a, b object) object {
+	return object{"bool": object{"filter": append(el.flatten("filter", a), el.flatten("filter", b)...)}}
+}
+
+func (el elastic) or(a, b object) object {
+	return object{"bool": object{"should": append(el.flatten("should", a), el.flatten("should", b)...), "minimum_should_match": 1}}
+}
+
+func (elastic) not(a object) object {
+	return object{"bool": object{"must_not": []object{a}}}
+}
+
+func (el elastic) exists(field string, exists bool) object {
+	q := object{"exists": object{"field": field}}
+	if !exists {
+		return el.not(q)
+	}
+	return q
+}
+
+func (el elastic) compare(field string, op TokenType, val interface{}) object {
+	switch op {
+	case tokEQ:
+		return object{"term": object{field: val}}
+	case tokNEQ:
+		return el.not(object{"term": object{field: val}})
+	}
+	return object{"range": object{field: object{elasticOps[op]: val}}}
+}
+
+func (elastic) match(field, re string) (object, bool) {
+	re, ok := luceneRegexp(re)
+	if !ok {
+		return nil, false
+	}
+	return object{"regexp": object{field: object{"value": re}}}, true
+}
+
+func (elastic) in(field string, vals []interface{}) object {
+	return object{"terms": object{field: vals}}
+}
+
+func (elastic) between(field string, r *Range, lo, hi interface{}) object {
+	loOp, hiOp := "gte", "lte"
+	if r.LoOpen {
+		loOp = "gt"
+	}
+	if r.HiOpen {
+		hiOp = "lt"
+	}
+	return object{"range": object{field: object{loOp: lo, hiOp: hi}}}
+}
+
+func (elastic) value(n Node) (interface{}, bool) {
+	switch {
+	case n.Big != nil:
+		return json.Number(n.String()), true
+	case n.Type == nodeTime:
+		return n.Time.Format(time.RFC3339Nano), true
+	}
+	return jsonValue(n)
+}
+
+// flatten returns the clauses of q if q is a bool query with nothing but
+// clauses of the given occurrence type.
+func (elastic) flatten(occur string, q object) []object {
+	b, ok := q["bool"].(object)
+	if !ok || len(q) != 1 {
+		return []object{q}
+	}
+	qs, ok := b[occur].([]object)
+	switch {
+	case !ok:
+	case occur == "filter" && len(b) == 1:
+		return qs
+	case occur == "should" && len(b) == 2 && b["minimum_should_match"] == 1:
+		return qs
+	}
+	return []object{q}
+}
+
+// luceneRegexp converts a regular expression, which matches anywhere in the
+// text, into the Lucene syntax used by Elasticsearch, which must match the
+// whole text.
+func luceneRegexp(re string) (string, bool) {
+	x, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	subs := []*syntax.Regexp{x}
+	if x.Op == syntax.OpConcat {
+		subs = x.Sub
+	}
+
+	var b strings.Builder
+
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		subs = subs[1:]
+	} else {
+		b.WriteString(".*")
+	}
+
+	end := len(subs) > 0 && subs[len(subs)-1].Op == syntax.OpEndText
+	if end {
+		subs = subs[:len(subs)-1]
+	}
+
+	for _, sub := range subs {
+		if !writeLucene(&b, sub) {
+			return "", false
+		}
+	}
+
+	if !end {
+		b.WriteString(".*")
+	}
+
+	return b.String(), true
+}
+
+func writeLucene(b *strings.Builder, x *syntax.Regexp) bool {
+	switch x.Op {
+	case syntax.OpEmptyMatch:
+	case syntax.OpLiteral:
+		if x.Flags&syntax.FoldCase != 0 {
+			return false
+		}
+		for _, r := range x.Rune {
+			if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteByte('[')
+		for i := 0; i < len(x.Rune); i += 2 {
+			writeLuceneRune(b, x.Rune[i])
+			if x.Rune[i+1] != x.Rune[i] {
+				b.WriteByte('-')
+				writeLuceneRune(b, x.Rune[i+1])
+			}
+		}
+		b.WriteByte(']')
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('.')
+	case syntax.OpCapture:
+		b.WriteByte('(')
+		if !writeLucene(b, x.Sub[0]) {
+			return false
+		}
+		b.WriteByte(')')
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		b.WriteByte('(')
+		if !writeLucene(b, x.Sub[0]) {
+			return false
+		}
+		b.WriteByte(')')
+		switch x.Op {
+		case syntax.OpStar:
+			b.WriteByte('*')
+		case syntax.OpPlus:
+			b.WriteByte('+')
+		case syntax.OpQuest:
+			b.WriteByte('?')
+		case syntax.OpRepeat:
+			b.WriteString("{" + strconv.Itoa(x.Min) + ",")
+			if x.Max >= 0 {
+				b.WriteString(strconv.Itoa(x.Max))
+			}
+			b.WriteByte('}')
+		}
+	case syntax.OpConcat:
+		for _, sub := range x.Sub {
+			if !writeLucene(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		b.WriteByte('(')
+		for i, sub := range x.Sub {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			if !writeLucene(b, sub) {
+				return false
+			}
+		}
+		b.WriteByte(')')
+	default:
+		return false
+	}
+	return true
+}
+
+func writeLuceneRune(b *strings.Builder, r rune) {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
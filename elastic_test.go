@@ -0,0 +1,63 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestElasticsearch(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		rule     string
+		expected string
+	}{
+		{
+			rule:     `>=1 & <=400 | "US"`,
+			expected: `{"bool": {"should": [{"bool": {"filter": [{"range": {"col": {"gte": 1}}}, {"range": {"col": {"lte": 400}}}]}}, {"term": {"col": "US"}}], "minimum_should_match": 1}}`,
+		},
+		{
+			rule:     `in [1, 2, null, 5..7] & not in (1, 3]`,
+			expected: `{"bool": {"filter": [{"bool": {"should": [{"terms": {"col": [1, 2]}}, {"bool": {"must_not": [{"exists": {"field": "col"}}]}}, {"range": {"col": {"gte": 5, "lte": 7}}}], "minimum_should_match": 1}}, {"bool": {"must_not": [{"range": {"col": {"gt": 1, "lte": 3}}}]}}]}}`,
+		},
+		{
+			rule:     `~ "^ab+c$" & !~ "x.y" & ~ "\\d|[-a]"`,
+			expected: `{"bool": {"filter": [{"regexp": {"col": {"value": "a(b)+c"}}}, {"bool": {"must_not": [{"regexp": {"col": {"value": ".*x.y.*"}}}]}}, {"regexp": {"col": {"value": ".*[\\-0-9a].*"}}}]}}`,
+		},
+		{rule: `!= "a" & required`, expected: `{"bool": {"filter": [{"bool": {"must_not": [{"term": {"col": "a"}}]}}, {"exists": {"field": "col"}}]}}`},
+		{rule: `> now - 18y`, expected: `{"range": {"col": {"gt": "2006-06-01T00:00:00Z"}}}`},
+		{rule: `age (> 1 + 1) | == false`, expected: `{"bool": {"should": [{"range": {"age": {"gt": 2}}}, {"term": {"col": false}}], "minimum_should_match": 1}}`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithClock(clock))
+		require.NoError(t, err, test.rule)
+
+		q, err := px.Elasticsearch("col")
+		require.NoError(t, err, test.rule)
+		require.JSONEq(t, test.expected, string(q), test.rule)
+	}
+}
+
+func TestElasticsearchErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  string
+	}{
+		{rule: `~ "\\bword"`, err: `elasticsearch: this regular expression is not supported in "\"\\\\bword\""`},
+		{rule: `~ "(?i)abc"`, err: `elasticsearch: this regular expression is not supported in "\"(?i)abc\""`},
+		{rule: `== $.other`, err: `elasticsearch: a value that depends on the input is not supported in "$.other"`},
+		{rule: `"a" + "b" == 1`, err: `elasticsearch: a subject that is not a field is not supported in "\"a\" + \"b\""`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		_, err = px.Elasticsearch("col")
+		require.True(t, errors.Is(err, ErrUnsupported), test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
@@ -0,0 +1,50 @@
+package boat
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Resolver interface {
+	Resolve(name string) (Node, bool)
+}
+
+// resolveErrorer is implemented by resolvers that can explain why a name
+// failed to resolve.
+type resolveErrorer interface {
+	resolveError(name string) error
+}
+
+func unresolved(env Resolver, name string) error {
+	if r, ok := env.(resolveErrorer); ok {
+		return r.resolveError(name)
+	}
+	return unknownIdent(name)
+}
+
+func unknownIdent(name string) error {
+	return fmt.Errorf("unknown identifier %q", name)
+}
+
+type Env map[string]Node
+
+func (e Env) Resolve(name string) (Node, bool) {
+	n, ok := e[name]
+	return n, ok
+}
+
+func splitPath(name string) []string {
+	if name == "" {
+		return nil
+	}
+	if !strings.HasPrefix(name, "/") {
+		return strings.Split(name, ".")
+	}
+	segs := strings.Split(name[1:], "/")
+	for i, seg := range segs {
+		if strings.IndexByte(seg, '~') >= 0 {
+			segs[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		}
+	}
+	return segs
+}
@@ -0,0 +1,58 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEnvRules(t *testing.T) {
+	env := Env{
+		"age":          IntNode(21),
+		"country":      TextNode("US"),
+		"score":        FloatNode(7.5),
+		"user.name":    TextNode("kenta"),
+		"user.premium": BoolNode(true),
+		"nickname":     NullNode(),
+	}
+
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{rule: `age >= 18 & country "US"`, pass: true},
+		{rule: `age >= 18 & country "CA"`, pass: false},
+		{rule: `age (>=18 & <=20)`, pass: false},
+		{rule: `age + 10 > 30 | score < 5`, pass: true},
+		{rule: `!(country "CA")`, pass: true},
+		{rule: `user.name "kenta" & user.premium`, pass: true},
+		{rule: `user.name ~ "^ken" & country !~ "^C"`, pass: true},
+		{rule: `country == "US" & age != 20 & score = 7.5`, pass: true},
+		{rule: `!(age == 21) | country != "US"`, pass: false},
+		{rule: `nickname optional "kenta" & nickname == null`, pass: true},
+		{rule: `nickname (required | "kenta")`, pass: false},
+		{in: "21", rule: `age`, pass: true},
+		{in: "22", rule: `>age`, pass: true},
+		{in: "7", rule: `$ + 0.5 score & >5`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.EvalEnv(test.in, env)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test)
+	}
+}
+
+func TestUnknownIdentifier(t *testing.T) {
+	px, err := ParseRule(`age >= 18`)
+	require.NoError(t, err)
+
+	_, err = px.Eval("")
+	require.Error(t, err)
+
+	_, err = px.EvalEnv("", Env{"name": TextNode("kenta")})
+	require.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package boat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnsupported is wrapped by errors returned when part of a rule cannot be
+// translated to another query language.
+var ErrUnsupported = errors.New("not supported")
+
+// Position locates the offending part of a rule.
+type Position struct {
+	Offset  int    // byte offset of the offending token
+	End     int    // byte offset just past the offending token
+	Line    int    // line number, starting at 1
+	Column  int    // column number in runes, starting at 1
+	Token   string // offending token
+	Snippet string // line of the rule with a caret pointing at the offending token
+}
+
+// SyntaxError is returned when a rule fails to lex, parse, type check or fold.
+type SyntaxError struct {
+	Position
+	Err error // underlying error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// EvalError is returned when a rule fails while being evaluated.
+type EvalError struct {
+	Position
+	Err error // underlying error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList is returned by ParseRule with every problem found while lexing and
+// parsing a rule, ordered by offset, or with the first problem found while
+// checking its types and folding its constants.
+type ErrorList []*SyntaxError
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, err := range l {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (l ErrorList) has(err *SyntaxError) bool {
+	for _, e := range l {
+		if e == err {
+			return true
+		}
+	}
+	return false
+}
+
+func syntaxError(rule string, start, end int, err error) *SyntaxError {
+	return &SyntaxError{Position: position(rule, start, end), Err: err}
+}
+
+func position(rule string, start, end int) Position {
+	if start > len(rule) {
+		start = len(rule)
+	}
+	if end < start {
+		end = start
+	}
+	if end > len(rule) {
+		end = len(rule)
+	}
+
+	bol := strings.LastIndexByte(rule[:start], '\n') + 1
+	eol := strings.IndexByte(rule[start:], '\n')
+	if eol < 0 {
+		eol = len(rule)
+	} else {
+		eol += start
+	}
+
+	stop := end
+	if stop > eol {
+		stop = eol
+	}
+
+	width := utf8.RuneCountInString(rule[start:stop])
+	if width == 0 {
+		width = 1
+	}
+
+	var b strings.Builder
+	b.WriteString(rule[bol:eol])
+	b.WriteByte('\n')
+	for _, r := range rule[bol:start] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteString(strings.Repeat("^", width))
+
+	return Position{
+		Offset:  start,
+		End:     end,
+		Line:    strings.Count(rule[:start], "\n") + 1,
+		Column:  utf8.RuneCountInString(rule[bol:start]) + 1,
+		Token:   rule[start:end],
+		Snippet: b.String(),
+	}
+}
+
+func unsupported(rule, target string, x *Expr, what string) error {
+	start, end := span(x)
+	return fmt.Errorf("%s: %s is %w in %q", target, what, ErrUnsupported, rule[start:end])
+}
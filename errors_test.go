@@ -0,0 +1,163 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	cases := []struct {
+		rule    string
+		line    int
+		column  int
+		token   string
+		snippet string
+		err     string
+	}{
+		{
+			rule:    `age >= 18 & (country == "US"`,
+			line:    1,
+			column:  13,
+			token:   `(`,
+			snippet: "age >= 18 & (country == \"US\"\n            ^",
+			err:     `line 1, column 13: mismatched parenthesis`,
+		},
+		{
+			rule:    "age >= 18 &\ncountry ^ 5",
+			line:    2,
+			column:  9,
+			token:   `^`,
+			snippet: "country ^ 5\n        ^",
+			err:     `line 2, column 9: unexpected rune`,
+		},
+		{
+			rule:    "\"héllo\" + 1",
+			line:    1,
+			column:  1,
+			token:   `"héllo" + 1`,
+			snippet: "\"héllo\" + 1\n^^^^^^^^^^^",
+			err:     `line 1, column 1: cannot apply '+' to text and int`,
+		},
+		{
+			rule:    "\"é\" |\n\tx | 1/0",
+			line:    2,
+			column:  6,
+			token:   `1/0`,
+			snippet: "\tx | 1/0\n\t    ^^^",
+			err:     `line 2, column 6: '/': division by zero`,
+		},
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test.rule)
+
+		var errs ErrorList
+		require.True(t, errors.As(err, &errs), test.rule)
+		require.Len(t, errs, 1, test.rule)
+
+		var serr *SyntaxError
+		require.True(t, errors.As(err, &serr), test.rule)
+		require.Equal(t, test.line, serr.Line, test.rule)
+		require.Equal(t, test.column, serr.Column, test.rule)
+		require.Equal(t, test.token, serr.Token, test.rule)
+		require.Equal(t, test.token, test.rule[serr.Offset:serr.End], test.rule)
+		require.Equal(t, test.snippet, serr.Snippet, test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	px, err := ParseRule("$ >= 0 &\n  10 / $ > 1")
+	require.NoError(t, err)
+
+	_, err = px.Eval("0")
+
+	var eerr *EvalError
+	require.True(t, errors.As(err, &eerr))
+	require.True(t, errors.Is(err, ErrDivisionByZero))
+	require.Equal(t, 2, eerr.Line)
+	require.Equal(t, 3, eerr.Column)
+	require.Equal(t, `10 / $`, eerr.Token)
+	require.Equal(t, "  10 / $ > 1\n  ^^^^^^", eerr.Snippet)
+	require.EqualError(t, err, `line 2, column 3: error while evaluating op: '/': division by zero`)
+
+	px, err = ParseRule(`>= 1 & len(name) > 3`)
+	require.NoError(t, err)
+
+	_, err = px.Eval("5")
+	require.True(t, errors.As(err, &eerr))
+	require.Equal(t, `name`, eerr.Token)
+	require.Equal(t, 12, eerr.Column)
+}
+
+func TestErrorList(t *testing.T) {
+	cases := []struct {
+		rule string
+		errs []string
+	}{
+		{
+			rule: `age >= 18 & country ^ 5 & name ~ "[" & x`,
+			errs: []string{
+				"line 1, column 21: unexpected rune",
+				"line 1, column 35: invalid regular expression: error parsing regexp: missing closing ]: `[`",
+			},
+		},
+		{
+			rule: `[1 ^ 2, 3] | in [x, 2]`,
+			errs: []string{
+				"line 1, column 4: unexpected rune",
+				"line 1, column 18: list items must be constant",
+			},
+		},
+		{
+			rule: `"a\q b" | (1 2 | $ ^ 3)`,
+			errs: []string{
+				"line 1, column 2: got invalid escape sequence literal",
+				`line 1, column 14: unexpected "2"`,
+				"line 1, column 20: unexpected rune",
+			},
+		},
+		{
+			rule: `age >= & 5 )`,
+			errs: []string{
+				`line 1, column 8: unexpected "&"`,
+				"line 1, column 12: mismatched parenthesis",
+			},
+		},
+		{
+			rule: `in [1 2 3]`,
+			errs: []string{`line 1, column 7: unexpected "2"`},
+		},
+		{
+			rule: `len(1 2 3) & x in [4 5]`,
+			errs: []string{
+				`line 1, column 7: unexpected "2"`,
+				`line 1, column 22: unexpected "5"`,
+			},
+		},
+		{
+			rule: `len(1,`,
+			errs: []string{"line 1, column 7: unexpected eof"},
+		},
+		{
+			rule: `2020-01-01x1`,
+			errs: []string{"line 1, column 1: invalid time literal"},
+		},
+		{
+			rule: `2020-01-0112`,
+			errs: []string{"line 1, column 1: invalid time literal"},
+		},
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test.rule)
+
+		var errs ErrorList
+		require.True(t, errors.As(err, &errs), test.rule)
+		require.Len(t, errs, len(test.errs), test.rule)
+		for i, err := range errs {
+			require.EqualError(t, err, test.errs[i], test.rule)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package boat
+
+type Expr struct {
+	Token           // operator or literal token
+	Val   Node      // decoded literal value, or identifier name
+	Args  []*Expr   // operands
+	fn    Func      // called func
+	sig   signature // called func signature
+	types typeSet   // possible value types
+}
+
+func (x *Expr) literal() bool {
+	switch x.Type {
+	case tokInt, tokFloat, tokText, tokTrue, tokFalse, tokNull, tokTime, tokDuration, tokWeekday, tokList, tokInterval:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package boat
+
+var literalTypes = [...]TokenType{
+	nodeInt:      tokInt,
+	nodeFloat:    tokFloat,
+	nodeText:     tokText,
+	nodeNull:     tokNull,
+	nodeList:     tokList,
+	nodeRange:    tokInterval,
+	nodeTime:     tokTime,
+	nodeDuration: tokDuration,
+}
+
+func (e *Rule) fold(x *Expr) (*Expr, error) {
+	if x.literal() {
+		return x, nil
+	}
+
+	for i, arg := range x.Args {
+		arg, err := e.fold(arg)
+		if err != nil {
+			return nil, err
+		}
+		x.Args[i] = arg
+	}
+
+	switch {
+	case x.Type == tokCall && x.sig.check != nil:
+		for i, arg := range x.Args {
+			if !arg.literal() {
+				continue
+			}
+			if err := x.sig.check(i, arg.Val); err != nil {
+				start, end := span(arg)
+				return nil, syntaxError(e.rule, start, end, err)
+			}
+		}
+		return x, nil
+	case x.Type == tokNegate || x.Type == tokBitNot:
+		if arg := x.Args[0]; arg.Type == x.Type {
+			return arg.Args[0], nil
+		}
+	case x.Type == tokBang:
+		if arg := x.Args[0]; arg.Type == tokBang && boolean(arg.Args[0]) {
+			return arg.Args[0], nil
+		}
+		if val, ok := boolConst(x.Args[0]); ok {
+			return folded(x, Node{Type: nodeBool, Bool: !val}), nil
+		}
+		return x, nil
+	case x.Type == tokAND || x.Type == tokOR:
+		short := x.Type == tokOR
+		for i, arg := range x.Args {
+			val, ok := boolConst(arg)
+			switch {
+			case !ok:
+			case val == short:
+				return folded(x, Node{Type: nodeBool, Bool: short}), nil
+			case boolean(x.Args[1-i]):
+				return x.Args[1-i], nil
+			}
+		}
+		return x, nil
+	case !isBinaryOp(x.Type):
+		return x, nil
+	}
+
+	args := make([]Node, len(x.Args))
+	for i, arg := range x.Args {
+		if !arg.literal() {
+			return x, nil
+		}
+		args[i] = arg.Val
+	}
+
+	r := Rule{big: e.big, overflow: e.overflow, vals: args}
+	if err := r.EvalOP(Node{}, x.Type); err != nil {
+		start, end := span(x)
+		return nil, syntaxError(e.rule, start, end, err)
+	}
+
+	return folded(x, r.vals[0]), nil
+}
+
+func folded(x *Expr, val Node) *Expr {
+	start, end := span(x)
+
+	typ := tokFalse
+	switch {
+	case val.Type != nodeBool:
+		typ = literalTypes[val.Type]
+	case val.Bool:
+		typ = tokTrue
+	}
+
+	// span widens text tokens by their quotes, so store the inner bounds
+	if typ == tokText {
+		start, end = start+1, end-1
+	}
+
+	return &Expr{Token: Token{Type: typ, Start: start, End: end}, Val: val, types: 1 << val.Type}
+}
+
+func boolConst(x *Expr) (bool, bool) {
+	return x.Val.Bool, x.literal() && x.Val.Type == nodeBool
+}
+
+func boolean(x *Expr) bool {
+	return x.types == 1<<nodeBool
+}
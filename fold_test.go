@@ -0,0 +1,60 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	cases := []struct {
+		rule string
+		code string
+	}{
+		{rule: `100/2`, code: "0000 CONST   0 (int 50)\n0001 TRUTH\n"},
+		{rule: `(1+2)*3`, code: "0000 CONST   0 (int 9)\n0001 TRUTH\n"},
+		{rule: `"hello " + "world"`, code: "0000 CONST   0 (text \"hello world\")\n0001 TRUTH\n"},
+		{rule: `>= 60 * 60 * 24`, code: "0000 CONST   0 (int 86400)\n0001 COMPARE >=\n"},
+		{rule: `< 2 ** 10 - 1 &&& 0xff`, code: "0000 CONST   0 (int 1023)\n0001 COMPARE <\n"},
+		{rule: `> now - (1d + 12h)`, code: "0000 NOW\n0001 CONST   0 (duration 36h0m0s)\n0002 ARITH   -\n0003 COMPARE >\n"},
+		{rule: `- -x`, code: "0000 LOAD    0 (x)\n0001 TRUTH\n"},
+		{rule: `!!(>= 5)`, code: "0000 CONST   0 (int 5)\n0001 COMPARE >=\n"},
+		{rule: `!!x`, code: "0000 LOAD    0 (x)\n0001 TRUTH\n0002 NOT\n0003 NOT\n"},
+		{rule: `x | true`, code: "0000 CONST   0 (bool true)\n"},
+		{rule: `false & x`, code: "0000 CONST   0 (bool false)\n"},
+		{rule: `>= 5 & true`, code: "0000 CONST   0 (int 5)\n0001 COMPARE >=\n"},
+		{rule: `false | <= 5`, code: "0000 CONST   0 (int 5)\n0001 COMPARE <=\n"},
+		{rule: `x & true`, code: "0000 LOAD    0 (x)\n0001 TRUTH\n0002 JUMPF   0004\n0003 CONST   1 (bool true)\n"},
+		{rule: `!true | !!false`, code: "0000 CONST   0 (bool false)\n"},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+		require.Equal(t, test.code, px.Disassemble(), test.rule)
+	}
+}
+
+func TestFoldErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  error
+	}{
+		{rule: `x | 1/0`, err: ErrDivisionByZero},
+		{rule: `>= 10 % (5 - 5)`, err: ErrDivisionByZero},
+		{rule: `9223372036854775807 + 1`, err: ErrOverflow},
+		{rule: `"a" * -1`, err: ErrRepeatCount},
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test.rule)
+		require.Error(t, err, test.rule)
+		require.True(t, errors.Is(err, test.err), test.rule)
+	}
+
+	_, err := ParseRule(`x | 1/0`)
+	require.EqualError(t, err, "line 1, column 5: '/': division by zero")
+
+	_, err = ParseRule(`9223372036854775807 + 1`, WithOverflow(OverflowSaturate))
+	require.NoError(t, err)
+}
@@ -0,0 +1,191 @@
+package boat
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format re-emits rule with normalized spacing, only the parentheses required
+// by Ops, double-quoted text and lower-case number literals.
+func Format(rule string) (string, error) {
+	p := parser{rule: rule, big: true, lax: true, m: NewMachine(rule)}
+
+	x, err := p.parse()
+	if err != nil {
+		return "", err
+	}
+
+	f := formatter{rule: rule}
+	f.expr(x)
+
+	return f.b.String(), nil
+}
+
+type formatter struct {
+	rule string          // rule being formatted
+	b    strings.Builder // formatted rule
+}
+
+func (f *formatter) expr(x *Expr) {
+	if op, ok := f.binary(x); ok {
+		f.operand(x.Args[0], op, true)
+		switch op {
+		case tokRange:
+			// $.. would lex as an ident
+			if strings.HasSuffix(f.b.String(), "$") {
+				f.b.WriteString(" .. ")
+			} else {
+				f.b.WriteString("..")
+			}
+		case tokSubject:
+			f.b.WriteByte(' ')
+		default:
+			f.b.WriteString(" " + op.String() + " ")
+		}
+		f.operand(x.Args[1], op, false)
+		return
+	}
+
+	if prefix(x) {
+		f.b.WriteString(x.Type.String())
+		if isCompareOp(x.Type) || x.Type == tokRequired || x.Type == tokOptional {
+			f.b.WriteByte(' ')
+		}
+		f.operand(x.Args[0], x.Type, false)
+		return
+	}
+
+	switch x.Type {
+	case tokInterval:
+		lo, hi := "[", "]"
+		if x.Val.Range.LoOpen {
+			lo = "("
+		}
+		if x.Val.Range.HiOpen {
+			hi = ")"
+		}
+		f.b.WriteString(lo)
+		f.list(x.Args)
+		f.b.WriteString(hi)
+	case tokList:
+		f.b.WriteByte('[')
+		f.list(x.Args)
+		f.b.WriteByte(']')
+	case tokCall:
+		args := x.Args
+		if len(args) > 0 && args[0].Type == tokInput && args[0].Start == args[0].End {
+			args = args[1:]
+		}
+		f.b.WriteString(x.Val.Text)
+		if f.rule[x.End-1] == ')' {
+			f.b.WriteByte('(')
+			f.list(args)
+			f.b.WriteByte(')')
+		}
+	case tokText:
+		f.b.WriteString(strconv.Quote(x.Val.Text))
+	case tokPointer:
+		f.b.WriteString("$" + strconv.Quote(x.Val.Text))
+	case tokInt, tokFloat:
+		f.b.WriteString(strings.ToLower(x.repr(f.rule)))
+	case tokTime:
+		f.b.WriteString(strings.ToUpper(x.repr(f.rule)))
+	case tokRequired, tokOptional, tokInput, tokNow:
+		f.b.WriteString(x.Type.String())
+	default:
+		f.b.WriteString(x.repr(f.rule))
+	}
+}
+
+func (f *formatter) list(items []*Expr) {
+	for i, item := range items {
+		if i > 0 {
+			f.b.WriteString(", ")
+		}
+		f.expr(item)
+	}
+}
+
+func (f *formatter) operand(x *Expr, op TokenType, left bool) {
+	if f.parens(x, op, left) {
+		f.b.WriteByte('(')
+		f.expr(x)
+		f.b.WriteByte(')')
+		return
+	}
+	f.expr(x)
+}
+
+// parens reports whether x needs parentheses to be parsed back as the lhs or
+// rhs of op.
+func (f *formatter) parens(x *Expr, op TokenType, left bool) bool {
+	o := Ops[op]
+
+	if xop, ok := f.binary(x); ok {
+		if p := Ops[xop].prec; p < o.prec || p == o.prec && left == o.rtl {
+			return true
+		}
+	}
+
+	switch {
+	case left:
+		return f.open(x) <= o.prec
+	case op == tokBang:
+		lead := f.leading(x)
+		return isCompareOp(lead.Type) || lead.Type == tokBitNot
+	case op == tokSubject:
+		return f.leading(x).Type == tokNegate
+	case op == tokRequired || op == tokOptional:
+		lead := f.leading(x)
+		return lead.Type == tokNegate || lead.Type == tokBitNot
+	}
+	return false
+}
+
+// open returns the lowest precedence of a prefix op on the right edge of x,
+// which would otherwise swallow any op that follows x.
+func (f *formatter) open(x *Expr) int {
+	if op, ok := f.binary(x); ok && !f.parens(x.Args[1], op, false) {
+		return f.open(x.Args[1])
+	}
+	if prefix(x) {
+		prec := Ops[x.Type].prec
+		if f.parens(x.Args[0], x.Type, false) {
+			return prec
+		}
+		if open := f.open(x.Args[0]); open < prec {
+			return open
+		}
+		return prec
+	}
+	return math.MaxInt32
+}
+
+// leading returns the expr printed first when formatting x.
+func (f *formatter) leading(x *Expr) *Expr {
+	if op, ok := f.binary(x); ok && !f.parens(x.Args[0], op, true) {
+		return f.leading(x.Args[0])
+	}
+	return x
+}
+
+func (f *formatter) binary(x *Expr) (TokenType, bool) {
+	switch {
+	case isBinaryOp(x.Type), x.Type == tokSubject:
+		return x.Type, true
+	case x.Type == tokInterval && x.repr(f.rule) == "..":
+		return tokRange, true
+	}
+	return 0, false
+}
+
+func prefix(x *Expr) bool {
+	switch x.Type {
+	case tokNegate, tokBitNot, tokBang:
+		return true
+	case tokRequired, tokOptional:
+		return len(x.Args) > 0
+	}
+	return isCompareOp(x.Type)
+}
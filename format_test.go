@@ -0,0 +1,56 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{in: `123 +456 |  "hello "`, out: `123 + 456 | "hello "`},
+		{in: `>=1 & <=400 | "hello " + name`, out: `>= 1 & <= 400 | "hello " + name`},
+		{in: `$ &&& 0XF0 == 0XF0 | $ > 1E5 | $ == 0B101`, out: `$ &&& 0xf0 == 0xf0 | $ > 1e5 | $ == 0b101`},
+		{in: `((a + b) * c) - (d - e) - (f)`, out: `(a + b) * c - (d - e) - f`},
+		{in: `2 ** (3 ** 2) == (2 ** 3) ** 2`, out: `2 ** 3 ** 2 == (2 ** 3) ** 2`},
+		{in: `-(1 + 2) == -3 & -(-x) == x`, out: `-(1 + 2) == -3 & --x == x`},
+		{in: `!(>= 5) & !(~~~x == 1)`, out: `!(>= 5) & !(~~~x == 1)`},
+		{in: `(required >= 5) & (optional)`, out: `(required >= 5) & optional`},
+		{in: `age >= 18 & (country == 'US' | country = "CA")`, out: `age >= 18 & (country == "US" | country == "CA")`},
+		{in: `$'/a/b' == 1 & $.year > 2 & year == 2020 & year($) == 1`, out: `$"/a/b" == 1 & $.year > 2 & year == 2020 & year($) == 1`},
+		{in: `in [1,2,-3] | in (1,5] | 1 .. 5 | x in (1..y)`, out: `in [1, 2, -3] | in (1, 5] | 1..5 | x in 1..y`},
+		{in: `> 2024-01-01t00:00:00z & < now-1h30m & weekday in [mon,fri]`, out: `> 2024-01-01T00:00:00Z & < now - 1h30m & weekday in [mon, fri]`},
+		{in: `len(name) > 3 & custom( a,b ) & tz("UTC") (hour in 9..17)`, out: `len(name) > 3 & custom(a, b) & tz("UTC") hour in 9..17`},
+		{in: `x (-1)`, out: `x (-1)`},
+		{in: `lower ($) == "a" & lower == "a"`, out: `lower($) == "a" & lower == "a"`},
+		{in: "age >= 18 &\n\tname ~ \"^a\\x41\"", out: `age >= 18 & name ~ "^aA"`},
+		{in: `a * (>= b) + c`, out: `(a * >= b) + c`},
+		{in: `$"/a"lower == "a"`, out: `$"/a" lower == "a"`},
+		{in: `optional (-1)`, out: `optional (-1)`},
+		{in: `x & required (~~~y)`, out: `x & required (~~~y)`},
+		{in: `$ .. "a" | x in $ .. 5`, out: `$ .. "a" | x in $ .. 5`},
+	}
+
+	for _, test := range cases {
+		out, err := Format(test.in)
+		require.NoError(t, err, test.in)
+		require.Equal(t, test.out, out, test.in)
+
+		again, err := Format(out)
+		require.NoError(t, err, out)
+		require.Equal(t, out, again, out)
+
+		px, err := ParseRule(test.in)
+		if err != nil {
+			continue
+		}
+		fx, err := ParseRule(out)
+		require.NoError(t, err, out)
+		require.Equal(t, px.Disassemble(), fx.Disassemble(), test.in)
+	}
+
+	_, err := Format(`age >= & 5`)
+	require.EqualError(t, err, `line 1, column 8: unexpected "&"`)
+}
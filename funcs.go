@@ -0,0 +1,87 @@
+package boat
+
+import "sync"
+
+type Func func(args ...Node) (Node, error)
+
+type funcDef struct {
+	fn    Func      // implementation
+	min   int       // min number of args
+	max   int       // max number of args, or -1 if variadic
+	input bool      // called with the input as first arg when it is omitted?
+	sig   signature // arg and result types
+}
+
+type signature struct {
+	args  []typeSet                 // accepted types of each arg, the last one repeating, or nil if unchecked
+	out   typeSet                   // result types, or 0 if unchecked
+	check func(i int, n Node) error // validates a constant arg, or nil if unchecked
+}
+
+type Registry struct {
+	parent *Registry          // parent registry
+	mu     sync.RWMutex       // guards funcs
+	funcs  map[string]funcDef // registered funcs
+}
+
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{parent: parent, funcs: make(map[string]funcDef)}
+}
+
+func (r *Registry) RegisterFunc(name string, fn func(args ...Node) (Node, error)) {
+	r.RegisterFuncArity(name, 0, -1, fn)
+}
+
+func (r *Registry) RegisterFuncArity(name string, min, max int, fn func(args ...Node) (Node, error)) {
+	r.mu.Lock()
+	r.funcs[name] = funcDef{fn: fn, min: min, max: max}
+	r.mu.Unlock()
+}
+
+func (r *Registry) registerInputFunc(name string, min, max int, fn Func) {
+	r.mu.Lock()
+	r.funcs[name] = funcDef{fn: fn, min: min, max: max, input: true}
+	r.mu.Unlock()
+}
+
+func (s signature) arg(i int) typeSet {
+	switch {
+	case len(s.args) == 0:
+		return anyType
+	case i >= len(s.args):
+		return s.args[len(s.args)-1]
+	}
+	return s.args[i]
+}
+
+func (r *Registry) typed(name string, out typeSet, args ...typeSet) {
+	r.mu.Lock()
+	def := r.funcs[name]
+	def.sig.args, def.sig.out = args, out
+	r.funcs[name] = def
+	r.mu.Unlock()
+}
+
+func (r *Registry) checked(name string, check func(i int, n Node) error) {
+	r.mu.Lock()
+	def := r.funcs[name]
+	def.sig.check = check
+	r.funcs[name] = def
+	r.mu.Unlock()
+}
+
+func (r *Registry) lookup(name string) (funcDef, bool) {
+	for ; r != nil; r = r.parent {
+		if def, ok := r.lookupLocal(name); ok {
+			return def, true
+		}
+	}
+	return builtins.lookupLocal(name)
+}
+
+func (r *Registry) lookupLocal(name string) (funcDef, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.funcs[name]
+	return def, ok
+}
@@ -0,0 +1,78 @@
+package boat_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lithdew/boat"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func hasPrefix(args ...boat.Node) (boat.Node, error) {
+	for _, arg := range args {
+		if arg.Type != boat.TextType {
+			return boat.Node{}, fmt.Errorf("hasPrefix: expected text, got %s", arg.Type)
+		}
+	}
+	return boat.BoolNode(strings.HasPrefix(args[0].Text, args[1].Text)), nil
+}
+
+func ExampleRegistry_RegisterFuncArity() {
+	reg := boat.NewRegistry(nil)
+	reg.RegisterFuncArity("hasPrefix", 2, 2, hasPrefix)
+
+	rule, err := boat.ParseRule(`hasPrefix($, "SKU-")`, boat.WithRegistry(reg))
+	if err != nil {
+		panic(err)
+	}
+
+	pass, err := rule.Eval(`SKU-1`)
+	fmt.Println(pass, err)
+
+	_, err = rule.Eval(`42`)
+	fmt.Println(err)
+
+	// Output:
+	// true <nil>
+	// line 1, column 1: error calling hasPrefix: hasPrefix: expected text, got int
+}
+
+func TestExternalFunc(t *testing.T) {
+	reg := boat.NewRegistry(nil)
+	reg.RegisterFuncArity("hasPrefix", 2, 2, hasPrefix)
+	reg.RegisterFuncArity("kind", 1, 1, func(args ...boat.Node) (boat.Node, error) {
+		switch args[0].Type {
+		case boat.IntType, boat.FloatType:
+			return boat.TextNode("number"), nil
+		case boat.NullType:
+			return boat.Node{}, errors.New("kind: missing value")
+		}
+		return boat.TextNode(args[0].Type.String()), nil
+	})
+
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: `SKU-1`, rule: `hasPrefix($, "SKU-")`, pass: true},
+		{in: `1.5`, rule: `kind($) "number"`, pass: true},
+		{in: `true`, rule: `kind($) == "bool"`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := boat.ParseRule(test.rule, boat.WithRegistry(reg))
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.Equal(t, test.pass, pass, test.rule)
+	}
+
+	px, err := boat.ParseRule(`kind($) "number"`, boat.WithRegistry(reg))
+	require.NoError(t, err)
+
+	_, err = px.Eval(``)
+	require.Error(t, err)
+}
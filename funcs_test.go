@@ -0,0 +1,119 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func testRegistry() *Registry {
+	reg := NewRegistry(nil)
+	reg.RegisterFuncArity("len", 1, 1, func(args ...Node) (Node, error) {
+		if args[0].Type != nodeText {
+			return Node{}, errors.New("arg must be text")
+		}
+		return IntNode(int64(len(args[0].Text))), nil
+	})
+	reg.RegisterFuncArity("lower", 1, 1, func(args ...Node) (Node, error) {
+		return TextNode(strings.ToLower(args[0].Text)), nil
+	})
+	reg.RegisterFunc("concat", func(args ...Node) (Node, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			b.WriteString(arg.Text)
+		}
+		return TextNode(b.String()), nil
+	})
+	reg.RegisterFuncArity("sku", 1, 2, func(args ...Node) (Node, error) {
+		prefix := "SKU-"
+		if len(args) > 1 {
+			prefix = args[1].Text
+		}
+		return BoolNode(strings.HasPrefix(args[0].Text, prefix)), nil
+	})
+	return reg
+}
+
+func TestFuncs(t *testing.T) {
+	reg := testRegistry()
+
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "abc", rule: `len($) >= 3`, pass: true},
+		{in: "ab", rule: `len($) >= 3`, pass: false},
+		{in: "ADMIN", rule: `lower($) "admin"`, pass: true},
+		{in: "ADMIN", rule: `lower ($) == "admin" & len($) (>=1 & <=5)`, pass: true},
+		{in: "abc", rule: `concat("a", "b", "c")`, pass: true},
+		{in: "", rule: `concat() == ""`, pass: true},
+		{in: "SKU-1", rule: `sku($)`, pass: true},
+		{in: "X-1", rule: `sku($, "X-") & !sku($)`, pass: true},
+		{in: "abcd", rule: `len(concat($, "ef")) == 6`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithRegistry(reg))
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestFuncScopes(t *testing.T) {
+	base := testRegistry()
+
+	child := NewRegistry(base)
+	child.RegisterFuncArity("lower", 1, 1, func(args ...Node) (Node, error) {
+		return TextNode("overridden"), nil
+	})
+
+	px, err := ParseRule(`lower($) "overridden" & len($) 3`, WithRegistry(child))
+	require.NoError(t, err)
+
+	pass, err := px.Eval("ABC")
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	_, err = ParseRule(`sku($)`)
+	require.Error(t, err)
+
+	_, err = ParseRule(`sku($)`, WithRegistry(NewRegistry(nil)))
+	require.Error(t, err)
+
+	px, err = ParseRule(`sku ($)`)
+	require.NoError(t, err)
+
+	_, err = px.Eval("abc")
+	require.Error(t, err)
+}
+
+func TestFuncErrors(t *testing.T) {
+	reg := testRegistry()
+
+	cases := []string{
+		`len()`,
+		`len($, $)`,
+		`sku()`,
+		`sku($, "a", "b")`,
+		`len($`,
+		`len($,)`,
+		`len($ +)`,
+		`unknown($)`,
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test, WithRegistry(reg))
+		require.Error(t, err, test)
+	}
+
+	px, err := ParseRule(`len(1) > 0`, WithRegistry(reg))
+	require.NoError(t, err)
+
+	_, err = px.Eval("")
+	require.Error(t, err)
+}
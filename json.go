@@ -0,0 +1,199 @@
+package boat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// paths that are not present in the document, such as missing object keys,
+// out of range array indices or fields of scalars, resolve to null, as nil
+// pointers do in EvalStruct
+var jsonNull = []byte("null")
+
+type jsonResolver struct {
+	doc []byte // json document
+	big bool   // decode numbers as big numbers?
+}
+
+func (e *Rule) EvalJSON(doc []byte) (bool, error) {
+	if !json.Valid(doc) {
+		return false, errors.New("eval json: invalid json document")
+	}
+
+	in := Node{Type: nodeNull}
+	if root, ok := jsonLookup(doc, nil); ok {
+		if n, ok := jsonNode(root, e.big); ok {
+			in = n
+		}
+	}
+
+	return e.eval(in, jsonResolver{doc: doc, big: e.big})
+}
+
+func (r jsonResolver) Resolve(name string) (Node, bool) {
+	val, ok := jsonLookup(r.doc, splitPath(name))
+	if !ok {
+		return Node{}, false
+	}
+	return jsonNode(val, r.big)
+}
+
+func jsonLookup(doc []byte, path []string) ([]byte, bool) {
+	i := skipSpace(doc, 0)
+
+	for _, seg := range path {
+		if i >= len(doc) {
+			return nil, false
+		}
+
+		switch doc[i] {
+		case '{':
+			i = skipSpace(doc, i+1)
+			found := false
+			for doc[i] != '}' {
+				end := skipValue(doc, i)
+				key := doc[i:end]
+
+				i = skipSpace(doc, end)
+				i = skipSpace(doc, i+1) // ':'
+
+				if jsonKeyEquals(key, seg) {
+					found = true
+					break
+				}
+
+				i = skipSpace(doc, skipValue(doc, i))
+				if doc[i] == ',' {
+					i = skipSpace(doc, i+1)
+				}
+			}
+			if !found {
+				return jsonNull, true
+			}
+		case '[':
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 {
+				return jsonNull, true
+			}
+			i = skipSpace(doc, i+1)
+			for ; idx > 0 && doc[i] != ']'; idx-- {
+				i = skipSpace(doc, skipValue(doc, i))
+				if doc[i] == ',' {
+					i = skipSpace(doc, i+1)
+				}
+			}
+			if doc[i] == ']' {
+				return jsonNull, true
+			}
+		default:
+			return jsonNull, true
+		}
+	}
+
+	if i >= len(doc) {
+		return nil, false
+	}
+
+	return doc[i:skipValue(doc, i)], true
+}
+
+func jsonKeyEquals(key []byte, name string) bool {
+	key = key[1 : len(key)-1]
+	if bytes.IndexByte(key, '\\') < 0 {
+		return string(key) == name
+	}
+	val, ok := jsonText(key)
+	return ok && val == name
+}
+
+func jsonText(buf []byte) (string, bool) {
+	if bytes.IndexByte(buf, '\\') < 0 {
+		return string(buf), true
+	}
+
+	var val string
+	if err := json.Unmarshal(append(append([]byte{'"'}, buf...), '"'), &val); err != nil {
+		return "", false
+	}
+	return val, true
+}
+
+func jsonNode(val []byte, big bool) (Node, bool) {
+	if len(val) == 0 {
+		return Node{}, false
+	}
+
+	switch val[0] {
+	case '"':
+		text, ok := jsonText(val[1 : len(val)-1])
+		return Node{Type: nodeText, Text: text}, ok
+	case 't':
+		return Node{Type: nodeBool, Bool: true}, true
+	case 'f':
+		return Node{Type: nodeBool, Bool: false}, true
+	case 'n':
+		return Node{Type: nodeNull}, true
+	case '{', '[':
+		return Node{}, false
+	}
+
+	if big {
+		n, err := decode(string(val), true)
+		return n, err == nil
+	}
+
+	if bytes.IndexAny(val, ".eE") < 0 {
+		if num, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+			return Node{Type: nodeInt, Int: num}, true
+		}
+	}
+
+	num, err := strconv.ParseFloat(string(val), 64)
+	if err != nil {
+		return Node{}, false
+	}
+	return Node{Type: nodeFloat, Float: num}, true
+}
+
+func skipSpace(doc []byte, i int) int {
+	for i < len(doc) && isWhitespace(rune(doc[i])) {
+		i++
+	}
+	return i
+}
+
+func skipValue(doc []byte, i int) int {
+	depth := 0
+
+	for ; i < len(doc); i++ {
+		switch doc[i] {
+		case '"':
+			for i++; doc[i] != '"'; i++ {
+				if doc[i] == '\\' {
+					i++
+				}
+			}
+			if depth == 0 {
+				return i + 1
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',', ' ', '\t', '\n', '\r', ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return i
+}
@@ -0,0 +1,90 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEvalJSON(t *testing.T) {
+	doc := []byte(`{
+		"id": 9007199254740993,
+		"order": {
+			"total": 149.99,
+			"items": [{"sku": "A-1", "qty": 2}, {"sku": "B-2", "qty": 1}],
+			"paid": true,
+			"note": null,
+			"a/b": "slash",
+			"esc\"aped": "tab\there"
+		},
+		"country": "US"
+	}`)
+
+	cases := []struct {
+		rule string
+		pass bool
+	}{
+		{rule: `$.order.total > 100`, pass: true},
+		{rule: `order.total > 100 & country "US"`, pass: true},
+		{rule: `$"/order/total" (>=100 & <150)`, pass: true},
+		{rule: `$.order.items.0.sku "A-1" & $.order.items.1.qty 1`, pass: true},
+		{rule: `$"/order/items/1/sku" "A-1"`, pass: false},
+		{rule: `$"/order/a~1b" "slash"`, pass: true},
+		{rule: `$'/order/esc"aped' "tab\there"`, pass: true},
+		{rule: `order.paid & id 9007199254740993`, pass: true},
+		{rule: `order.paid == true & order.note == null`, pass: true},
+		{rule: `order.note optional ~ "^x"`, pass: true},
+		{rule: `order.note required ~ "^x"`, pass: false},
+		{rule: `nick optional`, pass: true},
+		{rule: `nick required`, pass: false},
+		{rule: `order.missing == null & missing.deep == null & order.note.x == null`, pass: true},
+		{rule: `$"/order/missing" 1`, pass: false},
+		{rule: `$'/order/items/5' == null & order.items.x == null & order.total.x == null`, pass: true},
+		{rule: `$'/order/items/5' required`, pass: false},
+		{rule: `required`, pass: false},
+		{rule: `$ == null & optional`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.EvalJSON(doc)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestEvalJSONUnresolved(t *testing.T) {
+	doc := []byte(`{"order": {"total": 10, "items": [1, 2], "note": null}}`)
+
+	cases := []string{
+		`order "x"`,
+		`order.items "x"`,
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test)
+		require.NoError(t, err, test)
+
+		_, err = px.EvalJSON(doc)
+		require.Error(t, err, test)
+	}
+
+	px, err := ParseRule(`order.total 10`)
+	require.NoError(t, err)
+
+	_, err = px.EvalJSON([]byte(`{"order": {"total": 10}`))
+	require.Error(t, err)
+}
+
+func TestEvalJSONScalar(t *testing.T) {
+	px, err := ParseRule(`>=100 & <=200`)
+	require.NoError(t, err)
+
+	pass, err := px.EvalJSON([]byte(" 150 \n"))
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	_, err = ParseRule(`$"order"`)
+	require.Error(t, err)
+}
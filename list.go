@@ -0,0 +1,109 @@
+package boat
+
+import (
+	"math"
+	"strings"
+)
+
+type listKey struct {
+	typ  NodeType
+	num  int64
+	real float64
+	text string
+}
+
+type List struct {
+	Items []Node               // items
+	set   map[listKey]struct{} // hashed items
+	rest  []Node               // items that cannot be hashed
+}
+
+// NewList hashes items for fast lookup, except text that reads as a time, as it
+// equals a time by instant but other text by content.
+func NewList(items ...Node) *List {
+	l := &List{Items: items, set: make(map[listKey]struct{}, len(items))}
+	for _, item := range items {
+		if _, ok := timeOf(item); ok && item.Type == nodeText {
+			l.rest = append(l.rest, item)
+		} else if key, ok := hashKey(item); ok {
+			l.set[key] = struct{}{}
+		} else {
+			l.rest = append(l.rest, item)
+		}
+	}
+	return l
+}
+
+func ListNode(items ...Node) Node {
+	return Node{Type: nodeList, List: NewList(items...)}
+}
+
+func hashKey(n Node) (listKey, bool) {
+	if n.Big != nil {
+		if n.Big.IsInt() && n.Big.Num().IsInt64() {
+			return listKey{typ: nodeInt, num: n.Int}, true
+		}
+		if f, exact := n.Big.Float64(); exact {
+			return hashKey(Node{Type: nodeFloat, Float: f})
+		}
+		return listKey{typ: nodeFloat, text: n.Big.RatString()}, true
+	}
+	switch n.Type {
+	case nodeBool:
+		if n.Bool {
+			return listKey{typ: nodeBool, num: 1}, true
+		}
+		return listKey{typ: nodeBool}, true
+	case nodeInt:
+		return listKey{typ: nodeInt, num: n.Int}, true
+	case nodeFloat:
+		if n.Float == math.Trunc(n.Float) && n.Float >= math.MinInt64 && n.Float < math.MaxInt64 {
+			return listKey{typ: nodeInt, num: int64(n.Float)}, true
+		}
+		if n.Float != n.Float {
+			return listKey{}, false
+		}
+		return listKey{typ: nodeFloat, real: n.Float}, true
+	case nodeText:
+		return listKey{typ: nodeText, text: n.Text}, true
+	case nodeNull:
+		return listKey{typ: nodeNull}, true
+	case nodeTime:
+		return listKey{typ: nodeTime, num: n.Time.UnixNano()}, true
+	case nodeDuration:
+		return listKey{typ: nodeDuration, num: n.Int, real: float64(n.Months)}, true
+	}
+	return listKey{}, false
+}
+
+func (l *List) Contains(n Node) bool {
+	if key, ok := hashKey(n); ok {
+		if _, ok := l.set[key]; ok {
+			return true
+		}
+	}
+	if t, ok := timeOf(n); ok && n.Type == nodeText {
+		if _, ok := l.set[listKey{typ: nodeTime, num: t.UnixNano()}]; ok {
+			return true
+		}
+	}
+	for _, item := range l.rest {
+		if item.Type == nodeRange && item.Range.Contains(n) || Equal(n, item) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *List) String() string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, item := range l.Items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(item.String())
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package boat
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "gold", rule: `in ["gold", "silver", 3]`, pass: true},
+		{in: "bronze", rule: `in ["gold", "silver", 3]`, pass: false},
+		{in: "3", rule: `in ["gold", "silver", 3]`, pass: true},
+		{in: "3.0", rule: `in ["gold", "silver", 3]`, pass: true},
+		{in: "3.5", rule: `in [1, 2.5, 3.5,]`, pass: true},
+		{in: "-2", rule: `in [-1, -2]`, pass: true},
+		{in: "bronze", rule: `not in ["gold", "silver"]`, pass: true},
+		{in: "gold", rule: `not   in ["gold", "silver"]`, pass: false},
+		{in: "gold", rule: `["gold", "silver"]`, pass: true},
+		{in: "", rule: `in [null, ""]`, pass: true},
+		{in: "true", rule: `in [true]`, pass: true},
+		{in: "x", rule: `in []`, pass: false},
+		{in: "1", rule: `in [[1], 2]`, pass: false},
+		{in: "gold", rule: `len($) in [3, 4] & $ in ["gold"]`, pass: true},
+		{in: "gold", rule: `upper($) not in ["GOLD"] | $ "gold"`, pass: true},
+		{in: "gold", rule: `upper($) not in ["GOLD"] | $ == ["gold"]`, pass: false},
+		{in: "2024-01-01T00:00:00Z", rule: `in [2024-01-01] & == 2024-01-01`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	cases := []string{
+		`in [1, 2`,
+		`in [1 2]`,
+		`in [$]`,
+		`in [1, len($)]`,
+		`in [,]`,
+		`["a"] == ["a"]`,
+		`in "abc"`,
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test)
+		require.Error(t, err, test)
+	}
+
+	px, err := ParseRule(`in x`)
+	require.NoError(t, err)
+
+	_, err = px.EvalEnv("a", Env{"x": TextNode("abc")})
+	require.Error(t, err)
+}
+
+func TestListEnv(t *testing.T) {
+	px, err := ParseRule(`country in allowed & tier not in ["free"]`)
+	require.NoError(t, err)
+
+	env := Env{
+		"country": TextNode("NZ"),
+		"tier":    TextNode("pro"),
+		"allowed": ListNode(TextNode("AU"), TextNode("NZ")),
+	}
+
+	pass, err := px.EvalEnv("", env)
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+func TestListTimes(t *testing.T) {
+	nodes := []Node{
+		TextNode("2024-01-01T00:00:00Z"),
+		TextNode("2024-01-01"),
+		TextNode("2024-01-01T01:00:00+01:00"),
+		TimeNode(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		TimeNode(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	eq, err := ParseRule(`a == b`)
+	require.NoError(t, err)
+	in, err := ParseRule(`a in x & a in y`)
+	require.NoError(t, err)
+
+	for _, a := range nodes {
+		for _, b := range nodes {
+			env := Env{"a": a, "b": b, "x": ListNode(b), "y": ListNode(IntNode(1), b, TextNode("x"))}
+
+			expected, err := eq.EvalEnv("", env)
+			require.NoError(t, err)
+
+			pass, err := in.EvalEnv("", env)
+			require.NoError(t, err)
+			require.Equal(t, expected, pass, "%s in [%s]", a, b)
+		}
+	}
+}
+
+func BenchmarkListRule(b *testing.B) {
+	codes := make([]string, 0, 26*26)
+	for i := 'A'; i <= 'Z'; i++ {
+		for j := 'A'; j <= 'Z'; j++ {
+			codes = append(codes, fmt.Sprintf("%q", string([]rune{i, j})))
+		}
+	}
+
+	px, err := ParseRule(`in [` + strings.Join(codes, ", ") + `]`)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pass, err := px.Eval(`ZZ`)
+		if !pass || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,17 +1,19 @@
 package boat
 
 import (
+	"errors"
+	"strings"
 	"unicode/utf8"
 )
 
 type Machine struct {
-	input string  // input
-	err   string  // error
-	buf   []Token // token buf
-	pos   int     // start pos (byte)
-	ptr   int     // end pos (byte)
-	cc    int     // end pos (char)
-	lcw   int     // last char width
+	input string    // input
+	errs  ErrorList // errors
+	buf   []Token   // token buf
+	pos   int       // start pos (byte)
+	ptr   int       // end pos (byte)
+	cc    int       // end pos (char)
+	lcw   int       // last char width
 }
 
 func NewMachine(input string) Machine {
@@ -24,6 +26,7 @@ func (m *Machine) next() rune {
 			m.error("went too far ahead")
 			return eof
 		}
+		m.lcw = 0
 		return eof
 	}
 	r, cw := utf8.DecodeRuneInString(m.input[m.ptr:])
@@ -33,13 +36,23 @@ func (m *Machine) next() rune {
 	return r
 }
 
+func (m *Machine) peek() rune {
+	if m.ptr >= len(m.input) {
+		return eof
+	}
+	r, _ := utf8.DecodeRuneInString(m.input[m.ptr:])
+	return r
+}
+
 func (m *Machine) backup() {
 	if m.lcw < 0 {
 		m.error("went back too far")
 	}
-	m.ptr -= m.lcw
+	if m.lcw > 0 {
+		m.ptr -= m.lcw
+		m.cc--
+	}
 	m.lcw = -1
-	m.cc--
 }
 
 func (m *Machine) accept(r rune) bool {
@@ -50,6 +63,21 @@ func (m *Machine) accept(r rune) bool {
 	return false
 }
 
+func (m *Machine) acceptKeyword(kw string) bool {
+	ptr := m.ptr
+	for ptr < len(m.input) && isWhitespace(rune(m.input[ptr])) {
+		ptr++
+	}
+	if ptr == m.ptr || !strings.HasPrefix(m.input[ptr:], kw) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(m.input[ptr+len(kw):]); isIdentRune(r) || isDecimalRune(r) {
+		return false
+	}
+	m.ptr, m.lcw = ptr+len(kw), -1
+	return true
+}
+
 func (m *Machine) emit(typ TokenType) {
 	m.buf = append(m.buf, Token{Type: typ, Start: m.pos, End: m.ptr})
 	m.ignore()
@@ -57,7 +85,8 @@ func (m *Machine) emit(typ TokenType) {
 
 func (m *Machine) error(err string) {
 	m.buf = append(m.buf, Token{Type: tokError, Start: m.pos, End: m.ptr})
-	m.err = err
+	m.errs = append(m.errs, syntaxError(m.input, m.pos, m.ptr, errors.New(err)))
+	m.ignore()
 }
 
 func (m *Machine) ignore() {
@@ -73,44 +102,92 @@ func (m *Machine) Next() Token {
 			m.ignore()
 		case isDecimalRune(r):
 			m.lexNumber(r)
+		case isIdentRune(r):
+			m.lexIdent()
 		default:
 			switch r {
 			case eof:
 				m.emit(tokEOF)
 			case '.':
-				m.lexNumber(r)
+				if m.peek() == '.' {
+					m.next()
+					m.emit(tokRange)
+				} else {
+					m.lexNumber(r)
+				}
+			case '$':
+				switch r := m.peek(); {
+				case r == '\'' || r == '"':
+					m.lexEscapedText(m.next(), tokPointer)
+				case r == '.' && !strings.HasPrefix(m.input[m.ptr:], ".."):
+					m.lexIdent()
+				default:
+					m.emit(tokInput)
+				}
 			case '\'', '"':
-				m.lexEscapedText(r)
+				m.lexEscapedText(r, tokText)
 			case '>':
-				if m.accept('=') {
+				switch {
+				case m.accept('='):
 					m.emit(tokGTE)
-				} else {
+				case m.accept('>'):
+					m.emit(tokShiftRight)
+				default:
 					m.emit(tokGT)
 				}
 			case '<':
-				if m.accept('=') {
+				switch {
+				case m.accept('='):
 					m.emit(tokLTE)
-				} else {
+				case m.accept('<'):
+					m.emit(tokShiftLeft)
+				default:
 					m.emit(tokLT)
 				}
 			case '!':
-				m.emit(tokBang)
+				switch {
+				case m.accept('='):
+					m.emit(tokNEQ)
+				case m.accept('~'):
+					m.emit(tokNotMatch)
+				default:
+					m.emit(tokBang)
+				}
+			case '=':
+				m.accept('=')
+				m.emit(tokEQ)
+			case '~':
+				m.lexTriple(r, tokMatch, tokBitNot)
+			case '^':
+				m.lexTriple(r, tokError, tokBitXor)
 			case '+':
 				m.emit(tokPlus)
 			case '-':
 				m.emit(tokMinus)
 			case '*':
-				m.emit(tokMultiply)
+				if m.accept('*') {
+					m.emit(tokPower)
+				} else {
+					m.emit(tokMultiply)
+				}
 			case '/':
 				m.emit(tokDivide)
+			case '%':
+				m.emit(tokModulo)
 			case '(':
 				m.emit(tokBracketStart)
 			case ')':
 				m.emit(tokBracketEnd)
+			case ',':
+				m.emit(tokComma)
+			case '[':
+				m.emit(tokListStart)
+			case ']':
+				m.emit(tokListEnd)
 			case '&':
-				m.emit(tokAND)
+				m.lexTriple(r, tokAND, tokBitAnd)
 			case '|':
-				m.emit(tokOR)
+				m.lexTriple(r, tokOR, tokBitOr)
 			default:
 				m.error("unexpected rune")
 			}
@@ -123,6 +200,19 @@ func (m *Machine) Next() Token {
 	return token
 }
 
+func (m *Machine) lexTriple(r rune, single, triple TokenType) {
+	if m.ptr+1 < len(m.input) && rune(m.input[m.ptr]) == r && rune(m.input[m.ptr+1]) == r {
+		m.ptr, m.lcw = m.ptr+2, -1
+		m.emit(triple)
+		return
+	}
+	if single == tokError {
+		m.error("unexpected rune")
+		return
+	}
+	m.emit(single)
+}
+
 func (m *Machine) lexNumber(r rune) {
 	var (
 		separator bool
@@ -131,6 +221,20 @@ func (m *Machine) lexNumber(r rune) {
 	)
 
 	float := r == '.'
+	start := m.ptr - m.lcw
+
+	if len(m.input) >= start+10 && m.input[start+4] == '-' {
+		if loc := timeLiteral.FindStringIndex(m.input[start:]); loc != nil {
+			m.ptr, m.lcw = start+loc[1], -1
+			if r := m.peek(); isIdentRune(r) || isDecimalRune(r) {
+				m.next()
+				m.error("invalid time literal")
+				return
+			}
+			m.emit(tokTime)
+			return
+		}
+	}
 
 	skip := func(pred func(rune) bool) {
 		for {
@@ -152,16 +256,19 @@ func (m *Machine) lexNumber(r rune) {
 	}
 
 	if r == '0' {
-		prefix = lower(m.next())
+		prefix = lower(m.peek())
 
 		switch prefix {
 		case 'x':
+			m.next()
 			r = m.next()
 			skip(isHexRune)
 		case 'o':
+			m.next()
 			r = m.next()
 			skip(isOctalRune)
 		case 'b':
+			m.next()
 			r = m.next()
 			skip(isBinRune)
 		default:
@@ -173,7 +280,7 @@ func (m *Machine) lexNumber(r rune) {
 	}
 
 	if !float {
-		float = r == '.'
+		float = r == '.' && !strings.HasPrefix(m.input[m.ptr:], "..")
 	}
 
 	if float {
@@ -188,8 +295,6 @@ func (m *Machine) lexNumber(r rune) {
 		switch prefix {
 		case 'x':
 			skip(isHexRune)
-		case '0':
-			skip(isOctalRune)
 		default:
 			skip(isDecimalRune)
 		}
@@ -233,6 +338,16 @@ func (m *Machine) lexNumber(r rune) {
 
 	_ = separator
 
+	if isIdentRune(m.peek()) {
+		if prefix == 'x' || prefix == 'o' || prefix == 'b' {
+			m.next()
+			m.error("invalid digit in number literal")
+			return
+		}
+		m.lexDuration()
+		return
+	}
+
 	if float {
 		m.emit(tokFloat)
 	} else {
@@ -240,39 +355,98 @@ func (m *Machine) lexNumber(r rune) {
 	}
 }
 
-func (m *Machine) lexEscapedText(quote rune) {
+func (m *Machine) lexDuration() {
+	for {
+		start := m.ptr
+		for r := m.peek(); isIdentRune(r) && r != '_'; r = m.peek() {
+			m.next()
+		}
+		if _, ok := durationUnits[m.input[start:m.ptr]]; !ok {
+			m.error("invalid duration unit")
+			return
+		}
+		if !isDecimalRune(m.peek()) {
+			break
+		}
+		for r := m.peek(); isDecimalRune(r) || r == '_' || r == '.' && m.ptr+1 < len(m.input) && isDecimalRune(rune(m.input[m.ptr+1])); r = m.peek() {
+			m.next()
+		}
+	}
+	if isIdentRune(m.peek()) {
+		m.next()
+		m.error("invalid duration unit")
+		return
+	}
+	m.emit(tokDuration)
+}
+
+func (m *Machine) lexIdent() {
+	for {
+		r := m.next()
+		switch {
+		case isIdentRune(r), isDecimalRune(r):
+			continue
+		case r == '.' && (isIdentRune(m.peek()) || isDecimalRune(m.peek())):
+			continue
+		case r != eof:
+			m.backup()
+		}
+		break
+	}
+	if m.input[m.pos:m.ptr] == "not" && m.acceptKeyword("in") {
+		m.emit(tokNotIn)
+		return
+	}
+	if typ, ok := keywords[m.input[m.pos:m.ptr]]; ok {
+		m.emit(typ)
+		return
+	}
+	m.emit(tokIdent)
+}
+
+func (m *Machine) lexEscapedText(quote rune, typ TokenType) {
 	m.ignore()
 
-	for len(m.buf) == 0 {
+	var bad string
+
+	for {
 		switch m.next() {
 		case quote:
 			m.backup()
-			m.emit(tokText)
+			if bad != "" {
+				m.error(bad)
+			} else {
+				m.emit(typ)
+			}
 			m.next()
 			m.ignore()
 			return
 		case '\\':
-			m.lexEscape(quote)
-			continue
+			if err := m.lexEscape(quote); bad == "" {
+				bad = err
+			}
 		case eof, '\n':
 			m.error("unterminated string literal")
 			return
-		default:
-			continue
 		}
 	}
 }
 
-func (m *Machine) lexEscape(quote rune) {
+func (m *Machine) lexEscape(quote rune) string {
+	var bad string
+
 	r := m.next()
 
 	skip := func(n int, pred func(rune) bool) {
-		for n > 0 {
-			r = m.next()
-			if !pred(r) || r == eof {
-				m.error("got invalid escape sequence literal")
+		for ; n > 0; n-- {
+			if r = m.next(); r == quote || r == eof || r == '\n' {
+				m.backup()
+				bad = "got invalid escape sequence literal"
+				return
+			}
+			if !pred(r) {
+				bad = "got invalid escape sequence literal"
 			}
-			n--
 		}
 	}
 
@@ -285,12 +459,14 @@ func (m *Machine) lexEscape(quote rune) {
 		skip(4, isHexRune)
 	case 'U':
 		skip(8, isHexRune)
-	case eof:
-		m.error("reached eof while parsing escape sequence literal")
+	case eof, '\n':
+		m.backup()
 	default:
-		if !isOctalRune(r) || r == eof {
-			m.error("got invalid escape sequence literal")
+		if !isOctalRune(r) {
+			bad = "got invalid escape sequence literal"
 		}
 		skip(2, isOctalRune)
 	}
+
+	return bad
 }
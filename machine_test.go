@@ -2,6 +2,8 @@ package boat
 
 import (
 	"github.com/stretchr/testify/require"
+	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -10,6 +12,19 @@ func TestMachine(t *testing.T) {
 		`"hello" + "world"`,
 		`0xff 0xfd 1234.0e5 .196 123`,
 		`!(>=1 & <=400 | >=500 & <=600)`,
+		`0 0.5 0x1p-2 007`,
+		`age >= 18 & country "US"`,
+		`user.address.zip $ items.0 _private`,
+		`~ "^[a-z]+$" & !~ "x"`,
+		`= 1 == 2 != 3 !4`,
+		`true false null required optional`,
+		`len($) concat("a", "b")`,
+		`in ["gold", 3] not in [] not inside`,
+		`1..400 [1, 400) (1.5, 2.5] .5..1.`,
+		`5m 36h 250ms 1h30m 1.5h 18y 1y6mo 2µs 1h..2h`,
+		`2024-01-01 2024-01-01T09:30:00Z 2024-01-01T09:30:00.123+02:00 now - 18y`,
+		`$ % 2 ** 3 << 1 >> 2 &&& 0x4 ||| 1 ^^^ ~~~2`,
+		`weekday in [mon..fri] & at("0 9-17 * * 1-5")`,
 	}
 
 	for _, test := range cases {
@@ -23,3 +38,79 @@ func TestMachine(t *testing.T) {
 		require.NotEqual(t, tok.Type, tokError)
 	}
 }
+
+func TestMachineErrors(t *testing.T) {
+	cases := []string{
+		`0xfg`,
+		`12ab`,
+		`"hello world`,
+		`#`,
+		`5parsecs`,
+		`1h_`,
+		`^ 1`,
+		`2024-01-01T09:30:00Zulu`,
+		`2020-01-01x1`,
+		`2020-01-0112`,
+	}
+
+	for _, test := range cases {
+		m := NewMachine(test)
+
+		tok := m.Next()
+		for tok.Type != tokEOF && tok.Type != tokError {
+			tok = m.Next()
+		}
+
+		require.Equal(t, tok.Type, tokError, test)
+	}
+}
+
+func TestMachineTokens(t *testing.T) {
+	cases := []struct {
+		in  string
+		out []TokenType
+	}{
+		{in: `$..5`, out: []TokenType{tokInput, tokRange, tokInt}},
+		{in: `$ .. 5`, out: []TokenType{tokInput, tokRange, tokInt}},
+		{in: `$.a..5`, out: []TokenType{tokIdent, tokRange, tokInt}},
+		{in: `$'/a'..5`, out: []TokenType{tokPointer, tokRange, tokInt}},
+	}
+
+	for _, test := range cases {
+		m := NewMachine(test.in)
+
+		var out []TokenType
+		for tok := m.Next(); tok.Type != tokEOF && tok.Type != tokError; tok = m.Next() {
+			out = append(out, tok.Type)
+		}
+
+		require.Equal(t, test.out, out, test.in)
+	}
+}
+
+func TestMachineProgress(t *testing.T) {
+	fragments := []string{
+		`2020-01-01`, `x`, `1`, `12`, `T09:30:00Z`, `0x`, `1.`, `e`, `p+`, `"`, `'`, `\`, `\u12`, `$`, `$'`,
+		`.`, `..`, `#`, `^`, `~`, `&`, `|`, `!`, `(`, `)`, `[`, `]`, `,`, `h`, `mo`, `_`, ` `, "\n", `not`, `in`,
+	}
+
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 20000; i++ {
+		var b strings.Builder
+		for n := rng.Intn(8); n >= 0; n-- {
+			b.WriteString(fragments[rng.Intn(len(fragments))])
+		}
+		input := b.String()
+
+		m := NewMachine(input)
+
+		n := 0
+		for tok := m.Next(); tok.Type != tokEOF; tok = m.Next() {
+			n++
+			require.LessOrEqual(t, n, len(input)+1, input)
+		}
+
+		_, _ = ParseRule(input)
+	}
+}
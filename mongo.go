@@ -0,0 +1,126 @@
+package boat
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+var mongoOps = [...]string{
+	tokEQ:  "$eq",
+	tokNEQ: "$ne",
+	tokGT:  "$gt",
+	tokGTE: "$gte",
+	tokLT:  "$lt",
+	tokLTE: "$lte",
+}
+
+// Mongo translates the rule into a MongoDB filter document over field.
+func (e *Rule) Mongo(field string) ([]byte, error) {
+	return e.query(field, mongo{})
+}
+
+type mongo struct{}
+
+func (mongo) String() string {
+	return "mongodb"
+}
+
+func (mongo) all(match bool) object {
+	if match {
+		return object{}
+	}
+	return object{"$nor": []object{{}}}
+}
+
+func (m mongo) and(a, b object) object {
+	if q, ok := m.merge(a, b); ok {
+		return q
+	}
+	return object{"$and": append(m.flatten("$and", a), m.flatten("$and", b)...)}
+}
+
+func (m mongo) or(a, b object) object {
+	return object{"$or": append(m.flatten("$or", a), m.flatten("$or", b)...)}
+}
+
+func (mongo) not(a object) object {
+	return object{"$nor": []object{a}}
+}
+
+func (mongo) exists(field string, exists bool) object {
+	if exists {
+		return object{field: object{"$ne": nil}}
+	}
+	return object{field: object{"$eq": nil}}
+}
+
+func (mongo) compare(field string, op TokenType, val interface{}) object {
+	return object{field: object{mongoOps[op]: val}}
+}
+
+func (mongo) match(field, re string) (object, bool) {
+	return object{field: object{"$regex": re}}, true
+}
+
+func (mongo) in(field string, vals []interface{}) object {
+	return object{field: object{"$in": vals}}
+}
+
+func (mongo) between(field string, r *Range, lo, hi interface{}) object {
+	loOp, hiOp := "$gte", "$lte"
+	if r.LoOpen {
+		loOp = "$gt"
+	}
+	if r.HiOpen {
+		hiOp = "$lt"
+	}
+	return object{field: object{loOp: lo, hiOp: hi}}
+}
+
+func (mongo) value(n Node) (interface{}, bool) {
+	switch {
+	case n.Big != nil:
+		return json.Number(n.String()), true
+	case n.Type == nodeTime:
+		return object{"$date": n.Time.Format(time.RFC3339Nano)}, true
+	}
+	return jsonValue(n)
+}
+
+// merge combines the operators of two filters on the same field, as in
+// {"age": {"$gte": 1, "$lte": 400}}.
+func (mongo) merge(a, b object) (object, bool) {
+	if len(a) != 1 || len(b) != 1 {
+		return nil, false
+	}
+	for field, x := range a {
+		y, ok := b[field]
+		if !ok || strings.HasPrefix(field, "$") {
+			return nil, false
+		}
+		xops, xok := x.(object)
+		yops, yok := y.(object)
+		if !xok || !yok {
+			return nil, false
+		}
+		ops := make(object, len(xops)+len(yops))
+		for _, m := range []object{xops, yops} {
+			for op, val := range m {
+				if _, dup := ops[op]; dup || !strings.HasPrefix(op, "$") {
+					return nil, false
+				}
+				ops[op] = val
+			}
+		}
+		return object{field: ops}, true
+	}
+	return nil, false
+}
+
+func (mongo) flatten(op string, q object) []object {
+	if qs, ok := q[op].([]object); ok && len(q) == 1 {
+		return qs
+	}
+	return []object{q}
+}
@@ -0,0 +1,63 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMongo(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		rule     string
+		expected string
+	}{
+		{rule: `>=1 & <=400`, expected: `{"col": {"$gte": 1, "$lte": 400}}`},
+		{rule: `>=1 & <=400 | "US"`, expected: `{"$or": [{"col": {"$gte": 1, "$lte": 400}}, {"col": {"$eq": "US"}}]}`},
+		{
+			rule:     `in [1, 2, null, 5..7] & not in (1, 3]`,
+			expected: `{"$and": [{"$or": [{"col": {"$in": [1, 2]}}, {"col": {"$eq": null}}, {"col": {"$gte": 5, "$lte": 7}}]}, {"$nor": [{"col": {"$gt": 1, "$lte": 3}}]}]}`,
+		},
+		{rule: `~ "^a" & !~ "b"`, expected: `{"$and": [{"col": {"$regex": "^a"}}, {"$nor": [{"col": {"$regex": "b"}}]}]}`},
+		{rule: `(required >= 5) | $ == null`, expected: `{"$or": [{"col": {"$ne": null, "$gte": 5}}, {"col": {"$eq": null}}]}`},
+		{rule: `> now - 18y & < 2 ** 10 + 1`, expected: `{"col": {"$gt": {"$date": "2006-06-01T00:00:00Z"}, "$lt": 1025}}`},
+		{
+			rule:     `country in ["US", "CA"] & age >= 18 & $'/a/b~1c' == lower("X")`,
+			expected: `{"$and": [{"country": {"$in": ["US", "CA"]}}, {"age": {"$gte": 18}}, {"a.b/c": {"$eq": "x"}}]}`,
+		},
+		{rule: `true | false`, expected: `{}`},
+		{rule: `in []`, expected: `{"$nor": [{}]}`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithClock(clock))
+		require.NoError(t, err, test.rule)
+
+		q, err := px.Mongo("col")
+		require.NoError(t, err, test.rule)
+		require.JSONEq(t, test.expected, string(q), test.rule)
+	}
+}
+
+func TestMongoErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  string
+	}{
+		{rule: `== $.other`, err: `mongodb: a value that depends on the input is not supported in "$.other"`},
+		{rule: `"a" + "b" == 1`, err: `mongodb: a subject that is not a field is not supported in "\"a\" + \"b\""`},
+		{rule: `len($) > 3`, err: `mongodb: a subject that is not a field is not supported in "len($)"`},
+		{rule: `== 1h`, err: `mongodb: a duration value is not supported in "1h"`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		_, err = px.Mongo("col")
+		require.True(t, errors.Is(err, ErrUnsupported), test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
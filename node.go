@@ -2,8 +2,11 @@ package boat
 
 import (
 	"fmt"
+	"math/big"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -14,13 +17,36 @@ const (
 	nodeInt
 	nodeFloat
 	nodeText
+	nodeNull
+	nodeList
+	nodeRange
+	nodeTime
+	nodeDuration
+)
+
+// Node types, exported for funcs registered from outside this package.
+const (
+	BoolType     = nodeBool
+	IntType      = nodeInt
+	FloatType    = nodeFloat
+	TextType     = nodeText
+	NullType     = nodeNull
+	ListType     = nodeList
+	RangeType    = nodeRange
+	TimeType     = nodeTime
+	DurationType = nodeDuration
 )
 
 var nodeStr = [...]string{
-	nodeBool:  "bool",
-	nodeInt:   "int",
-	nodeFloat: "float",
-	nodeText:  "text",
+	nodeBool:     "bool",
+	nodeInt:      "int",
+	nodeFloat:    "float",
+	nodeText:     "text",
+	nodeNull:     "null",
+	nodeList:     "list",
+	nodeRange:    "range",
+	nodeTime:     "time",
+	nodeDuration: "duration",
 }
 
 func (t NodeType) String() string {
@@ -28,19 +54,99 @@ func (t NodeType) String() string {
 }
 
 type Node struct {
-	Type  NodeType
-	Bool  bool
-	Int   int64
-	Float float64
-	Text  string
+	Type   NodeType
+	Bool   bool
+	Int    int64
+	Float  float64
+	Text   string
+	Time   time.Time
+	Months int64
+	Big    *big.Rat
+	Re     *regexp.Regexp
+	List   *List
+	Range  *Range
+}
+
+func BoolNode(val bool) Node {
+	return Node{Type: nodeBool, Bool: val}
+}
+
+func IntNode(val int64) Node {
+	return Node{Type: nodeInt, Int: val}
+}
+
+func FloatNode(val float64) Node {
+	return Node{Type: nodeFloat, Float: val}
+}
+
+func TextNode(val string) Node {
+	return Node{Type: nodeText, Text: val}
+}
+
+func NullNode() Node {
+	return Node{Type: nodeNull}
+}
+
+func (n Node) String() string {
+	if n.Big != nil {
+		return formatBig(n)
+	}
+	switch n.Type {
+	case nodeBool:
+		return strconv.FormatBool(n.Bool)
+	case nodeInt:
+		return strconv.FormatInt(n.Int, 10)
+	case nodeFloat:
+		return strconv.FormatFloat(n.Float, 'g', -1, 64)
+	case nodeNull:
+		return "null"
+	case nodeList:
+		return n.List.String()
+	case nodeRange:
+		return n.Range.String()
+	case nodeTime:
+		return n.Time.Format(time.RFC3339Nano)
+	case nodeDuration:
+		return formatDuration(n)
+	default:
+		return strconv.Quote(n.Text)
+	}
 }
 
 func Decode(val string) (Node, error) {
+	return decode(val, false)
+}
+
+func DecodeBig(val string) (Node, error) {
+	return decode(val, true)
+}
+
+func decode(val string, big bool) (Node, error) {
 	var n Node
 
 	r, _ := utf8.DecodeRuneInString(val)
 
 	switch {
+	case val == "":
+		n.Type = nodeNull
+	case val == "true" || val == "false":
+		n.Type = nodeBool
+		n.Bool = val == "true"
+	case isTime(val):
+		t, err := parseTime(val)
+		if err != nil {
+			return n, fmt.Errorf("failed to decode time: %w", err)
+		}
+		n.Type = nodeTime
+		n.Time = t
+	case big && (r == '.' || r == '-' || isDecimalRune(r)):
+		var err error
+		if n, err = parseBigInt(val); err != nil {
+			n, err = parseBigFloat(val)
+		}
+		if err != nil {
+			return n, fmt.Errorf("failed to decode number: %w", err)
+		}
 	case r == '.' || r == '-' || isDecimalRune(r):
 		if strings.ContainsRune(val, '.') {
 			n.Type = nodeFloat
@@ -66,7 +172,106 @@ func Decode(val string) (Node, error) {
 
 func EvalNode(a, b Node) bool {
 	switch b.Type {
+	case nodeBool:
+		return b.Bool
+	case nodeList:
+		return b.List.Contains(a)
+	case nodeRange:
+		return b.Range.Contains(a)
+	default:
+		return Equal(a, b)
+	}
+}
+
+func Compare(a, b Node) (int, bool) {
+	if isBig(a, b) {
+		return compareBig(a, b)
+	}
+	switch a.Type {
 	case nodeInt:
+		switch b.Type {
+		case nodeInt:
+			return compareInts(a.Int, b.Int), true
+		case nodeFloat:
+			return compareFloats(float64(a.Int), b.Float)
+		}
+	case nodeFloat:
+		switch b.Type {
+		case nodeInt:
+			return compareFloats(a.Float, float64(b.Int))
+		case nodeFloat:
+			return compareFloats(a.Float, b.Float)
+		}
+	case nodeDuration:
+		if b.Type == nodeDuration {
+			return compareDurations(a, b), true
+		}
+	case nodeTime, nodeText:
+		if a.Type == nodeText && b.Type != nodeTime {
+			break
+		}
+		if at, ok := timeOf(a); ok {
+			if bt, ok := timeOf(b); ok {
+				return compareTimes(at, bt), true
+			}
+		}
+	}
+	return 0, false
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloats(a, b float64) (int, bool) {
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	case a == b:
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func Equal(a, b Node) bool {
+	switch b.Type {
+	case nodeBool:
+		return a.Type == nodeBool && a.Bool == b.Bool
+	case nodeNull:
+		return a.Type == nodeNull
+	case nodeRange:
+		return a.Type == nodeRange && a.Range.LoOpen == b.Range.LoOpen && a.Range.HiOpen == b.Range.HiOpen &&
+			Equal(a.Range.Lo, b.Range.Lo) && Equal(a.Range.Hi, b.Range.Hi)
+	case nodeList:
+		if a.Type != nodeList || len(a.List.Items) != len(b.List.Items) {
+			return false
+		}
+		for i := range a.List.Items {
+			if !Equal(a.List.Items[i], b.List.Items[i]) {
+				return false
+			}
+		}
+		return true
+	case nodeTime:
+		t, ok := timeOf(a)
+		return ok && t.Equal(b.Time)
+	case nodeDuration:
+		return a.Type == nodeDuration && a.Int == b.Int && a.Months == b.Months
+	case nodeInt:
+		if isBig(a, b) {
+			c, ok := compareBig(a, b)
+			return ok && c == 0
+		}
 		switch a.Type {
 		case nodeInt:
 			return a.Int == b.Int
@@ -76,6 +281,10 @@ func EvalNode(a, b Node) bool {
 			return false
 		}
 	case nodeFloat:
+		if isBig(a, b) {
+			c, ok := compareBig(a, b)
+			return ok && c == 0
+		}
 		switch a.Type {
 		case nodeFloat:
 			return a.Float == b.Float
@@ -84,9 +293,11 @@ func EvalNode(a, b Node) bool {
 		default:
 			return false
 		}
-	case nodeText:
-		return a.Type == nodeText && a.Text == b.Text
 	default:
-		return b.Bool
+		if a.Type == nodeTime {
+			t, ok := timeOf(b)
+			return ok && t.Equal(a.Time)
+		}
+		return a.Type == nodeText && a.Text == b.Text
 	}
 }
@@ -0,0 +1,65 @@
+package boat
+
+import (
+	"fmt"
+	"math"
+)
+
+func (e *Rule) evalIntOP(op TokenType, a, b Node) (Node, error) {
+	if a.Type == nodeInt && b.Type == nodeInt {
+		x, y := a.Int, b.Int
+
+		switch op {
+		case tokModulo:
+			if y == 0 {
+				return Node{}, fmt.Errorf(`'%%': %w`, ErrDivisionByZero)
+			}
+			return Node{Type: nodeInt, Int: x % y}, nil
+		case tokPower:
+			if y < 0 {
+				return Node{Type: nodeFloat, Float: math.Pow(float64(x), float64(y))}, nil
+			}
+			z, err := e.powInt(x, y)
+			return Node{Type: nodeInt, Int: z}, err
+		case tokShiftLeft:
+			z, err := e.shlInt(x, y)
+			return Node{Type: nodeInt, Int: z}, err
+		case tokShiftRight:
+			if y < 0 {
+				return Node{}, fmt.Errorf(`'%s': %w %d`, op, ErrShiftCount, y)
+			}
+			return Node{Type: nodeInt, Int: x >> uint64(y)}, nil
+		case tokBitAnd:
+			return Node{Type: nodeInt, Int: x & y}, nil
+		case tokBitOr:
+			return Node{Type: nodeInt, Int: x | y}, nil
+		case tokBitXor:
+			return Node{Type: nodeInt, Int: x ^ y}, nil
+		}
+	}
+
+	switch op {
+	case tokModulo, tokPower:
+		x, xok := floatOf(a)
+		y, yok := floatOf(b)
+		if !xok || !yok {
+			return Node{}, fmt.Errorf(`lhs and rhs for '%s' must be int or float`, op)
+		}
+		if op == tokModulo {
+			return Node{Type: nodeFloat, Float: math.Mod(x, y)}, nil
+		}
+		return Node{Type: nodeFloat, Float: math.Pow(x, y)}, nil
+	default:
+		return Node{}, fmt.Errorf(`lhs and rhs for '%s' must be int`, op)
+	}
+}
+
+func floatOf(n Node) (float64, bool) {
+	switch n.Type {
+	case nodeInt:
+		return float64(n.Int), true
+	case nodeFloat:
+		return n.Float, true
+	}
+	return 0, false
+}
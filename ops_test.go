@@ -0,0 +1,98 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestIntOpRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "42", rule: `$ % 2 == 0`, pass: true},
+		{in: "43", rule: `$ % 2 == 0`, pass: false},
+		{in: "45", rule: `$ % 15 == 0`, pass: true},
+		{in: "-7", rule: `$ % 3 == -1`, pass: true},
+		{in: "7.5", rule: `$ % 2 == 1.5`, pass: true},
+		{in: "6", rule: `$ &&& 0x4 != 0`, pass: true},
+		{in: "3", rule: `$ &&& 0x4 != 0`, pass: false},
+		{in: "5", rule: `$ ||| 2 == 7 & $ ^^^ 1 == 4 & ~~~$ == -6`, pass: true},
+		{in: "1", rule: `$ << 10 == 1024 & 1024 >> 3 == 128 & -16 >> 2 == -4`, pass: true},
+		{in: "2", rule: `$ ** 10 == 1024 & 2 ** 3 ** 2 == 512 & -2 ** 2 == -4`, pass: true},
+		{in: "2", rule: `$ ** -1 == 0.5 & 4 ** 0.5 == 2`, pass: true},
+		{in: "3", rule: `1 + $ * 2 % 4 == 3`, pass: true},
+		{in: "3", rule: `$ + 1 &&& 2 == 3 & $ ||| 4 + 1 == 8`, pass: true},
+		{in: "12", rule: `$ % 5 in 1..3`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestBigIntOpRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "18446744073709551616", rule: `$ % 2 == 0 & $ == 2 ** 64 & $ == 1 << 64`, pass: true},
+		{in: "18446744073709551615", rule: `$ &&& 0xff == 255 & $ >> 60 == 15 & ~~~$ == -18446744073709551616`, pass: true},
+		{in: "7.5", rule: `$ % 2 == 1.5 & $ ** 2 == 56.25 & 2 ** -2 == 0.25`, pass: true},
+		{in: "-7", rule: `$ % 3 == -1`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithBigNumbers())
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestIntOpErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		big  bool
+	}{
+		{rule: `$ % 0 == 1`},
+		{rule: `$ << -1 == 1`},
+		{rule: `$ &&& 1.5 == 1`},
+		{rule: `~~~1.5 == 1`},
+		{rule: `"a" % 2 == 1`},
+		{rule: `$ % 0 == 1`, big: true},
+		{rule: `$ ** 100000 == 1`, big: true},
+		{rule: `$ ** 0.5 == 1`, big: true},
+		{rule: `$ << 100000 == 1`, big: true},
+		{rule: `0 ** -1 == 1`, big: true},
+	}
+
+	for _, test := range cases {
+		var opts []Option
+		if test.big {
+			opts = append(opts, WithBigNumbers())
+		}
+
+		px, err := ParseRule(test.rule, opts...)
+		if err != nil {
+			continue
+		}
+
+		_, err = px.Eval("3")
+		require.Error(t, err, test.rule)
+	}
+
+	for _, rule := range []string{`$ ^ 2`, `$ && 1`, `$ ~~ 1`} {
+		_, err := ParseRule(rule)
+		require.Error(t, err, rule)
+	}
+}
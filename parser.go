@@ -0,0 +1,550 @@
+package boat
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	rule  string    // rule
+	funcs *Registry // function registry
+	big   bool      // big number mode?
+	m     Machine   // lexer
+	tok   Token     // current token
+	errs  ErrorList // errors found so far
+	quiet bool      // synced without consuming a token since?
+	lax   bool      // accept calls to unknown funcs?
+	days  bool      // parse weekday names as weekday literals?
+}
+
+func parse(rule string, funcs *Registry, big bool) (*Expr, error) {
+	p := parser{rule: rule, funcs: funcs, big: big, m: NewMachine(rule)}
+	return p.parse()
+}
+
+func (p *parser) parse() (*Expr, error) {
+	p.advance()
+
+	x := p.expr(0)
+
+	for p.tok.Type != tokEOF {
+		if p.tok.Type == tokBracketEnd {
+			p.fail(p.errorf(p.tok, "mismatched parenthesis"))
+			p.advance()
+			p.sync()
+			x = p.climb(x, 0)
+			continue
+		}
+		x = p.skip(x)
+	}
+
+	if errs := p.errors(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return x, nil
+}
+
+func (p *parser) advance() {
+	p.tok = p.m.Next()
+	p.quiet = false
+}
+
+func (p *parser) errorf(tok Token, format string, args ...interface{}) error {
+	return syntaxError(p.rule, tok.Start, tok.End, fmt.Errorf(format, args...))
+}
+
+func (p *parser) unexpected() error {
+	switch p.tok.Type {
+	case tokError:
+		return p.m.errs[len(p.m.errs)-1]
+	case tokEOF:
+		return p.errorf(p.tok, "unexpected eof")
+	default:
+		return p.errorf(p.tok, "unexpected %q", p.tok.repr(p.rule))
+	}
+}
+
+// fail records err, unless the parser has just synced after an earlier error
+// and is yet to consume the token it stopped at, as any error found there
+// would only cascade from the first.
+func (p *parser) fail(err error) {
+	if p.quiet {
+		return
+	}
+	serr := err.(*SyntaxError)
+	if n := len(p.errs); n > 0 && p.errs[n-1].Offset == serr.Offset {
+		return
+	}
+	p.errs = append(p.errs, serr)
+}
+
+func (p *parser) errors() ErrorList {
+	errs := p.errs
+	for _, err := range p.m.errs {
+		if !errs.has(err) {
+			errs = append(errs, err)
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+	return errs
+}
+
+// sync skips tokens up to the next operator, closing parenthesis, comma or the end of the rule.
+func (p *parser) sync() {
+	depth := 0
+	for ; p.tok.Type != tokEOF; p.advance() {
+		switch t := p.tok.Type; {
+		case t == tokBracketStart, t == tokListStart:
+			depth++
+		case t == tokBracketEnd, t == tokListEnd:
+			if depth == 0 {
+				p.quiet = true
+				return
+			}
+			depth--
+		case depth > 0:
+		case t == tokComma, isBinaryOp(t), isCompareOp(t):
+			p.quiet = true
+			return
+		}
+	}
+	p.quiet = true
+}
+
+// skip reports the current token as unexpected, resyncs past it and continues
+// parsing any operator found with x as its lhs.
+func (p *parser) skip(x *Expr) *Expr {
+	p.fail(p.unexpected())
+	p.advance()
+	p.sync()
+	return p.climb(x, 0)
+}
+
+func bad(tok Token) *Expr {
+	return &Expr{Token: Token{Type: tokError, Start: tok.Start, End: tok.Start}}
+}
+
+func (p *parser) expr(prec int) *Expr {
+	lhs, err := p.unary()
+	if err != nil {
+		p.fail(err)
+		p.sync()
+		lhs = bad(p.tok)
+	}
+	return p.climb(lhs, prec)
+}
+
+func (p *parser) climb(lhs *Expr, prec int) *Expr {
+	for {
+		op := p.tok
+
+		if !isBinaryOp(op.Type) {
+			if !startsOperand(op.Type) {
+				break
+			}
+			op.Type, op.End = tokSubject, op.Start
+		}
+
+		o := Ops[op.Type]
+
+		if o.prec < prec {
+			break
+		}
+
+		if op.Type == tokSubject && lhs.literal() {
+			return p.skip(lhs)
+		}
+
+		if op.Type != tokSubject {
+			p.advance()
+		}
+
+		next := o.prec + 1
+		if o.rtl {
+			next = o.prec
+		}
+
+		days := p.days
+		if op.Type == tokSubject {
+			p.days = lhs.Type == tokCall && lhs.Val.Text == "weekday"
+		}
+
+		rhs := p.expr(next)
+
+		p.days = days
+
+		lhs = &Expr{Token: op, Args: []*Expr{lhs, rhs}}
+
+		if op.Type == tokRange {
+			lhs = p.interval(lhs, false, false)
+		}
+	}
+
+	return lhs
+}
+
+func (p *parser) unary() (*Expr, error) {
+	tok := p.tok
+
+	switch tok.Type {
+	case tokMinus, tokBitNot, tokBang, tokEQ, tokNEQ, tokGT, tokGTE, tokLT, tokLTE, tokMatch, tokNotMatch, tokIn, tokNotIn:
+		if tok.Type == tokMinus {
+			tok.Type = tokNegate
+		}
+
+		p.advance()
+
+		x := p.expr(Ops[tok.Type].prec)
+
+		if tok.Type == tokMatch || tok.Type == tokNotMatch {
+			if err := p.regexp(x); err != nil {
+				return nil, err
+			}
+		}
+
+		return &Expr{Token: tok, Args: []*Expr{x}}, nil
+	case tokBracketStart:
+		p.advance()
+
+		x := p.expr(0)
+
+		if p.tok.Type == tokComma {
+			p.advance()
+
+			hi := p.expr(0)
+
+			if p.tok.Type != tokBracketEnd && p.tok.Type != tokListEnd {
+				return nil, p.unexpected()
+			}
+
+			x = &Expr{Token: tok, Args: []*Expr{x, hi}}
+			x.Type, x.End = tokInterval, p.tok.End
+			x = p.interval(x, true, p.tok.Type == tokBracketEnd)
+
+			p.advance()
+
+			return x, nil
+		}
+
+		for p.tok.Type != tokBracketEnd {
+			if p.tok.Type == tokEOF {
+				return nil, p.errorf(tok, "mismatched parenthesis")
+			}
+			x = p.skip(x)
+		}
+
+		p.advance()
+
+		return x, nil
+	case tokInt, tokFloat, tokText, tokTrue, tokFalse, tokNull, tokTime, tokDuration, tokWeekday:
+		p.advance()
+		return p.literal(tok)
+	case tokListStart:
+		return p.list()
+	case tokRequired, tokOptional:
+		p.advance()
+
+		if !startsOperand(p.tok.Type) {
+			return &Expr{Token: tok}, nil
+		}
+
+		x := p.expr(Ops[tok.Type].prec)
+
+		return &Expr{Token: tok, Args: []*Expr{x}}, nil
+	case tokIdent:
+		if _, ok := weekdays[tok.repr(p.rule)]; ok && p.days {
+			tok.Type = tokWeekday
+			p.advance()
+			return p.literal(tok)
+		}
+
+		p.advance()
+
+		name := strings.TrimPrefix(tok.repr(p.rule), "$.")
+
+		def, ok := p.funcs.lookup(name)
+		if !ok && p.lax && p.tok.Type == tokBracketStart && p.tok.Start == tok.End {
+			def, ok = funcDef{max: -1}, true
+		}
+
+		if p.tok.Type == tokBracketStart {
+			if ok {
+				return p.call(tok, name, def)
+			}
+			if p.tok.Start == tok.End {
+				return nil, p.errorf(tok, "unknown function %q", name)
+			}
+		}
+
+		if ok && def.input && def.min == 1 && name == tok.repr(p.rule) {
+			tok.Type = tokCall
+			return &Expr{Token: tok, Val: Node{Type: nodeText, Text: name}, Args: []*Expr{input(tok)}, fn: def.fn, sig: def.sig}, nil
+		}
+
+		return &Expr{Token: tok, Val: Node{Type: nodeText, Text: name}}, nil
+	case tokPointer:
+		p.advance()
+
+		name, err := unescape(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to unescape json pointer: %s", err)
+		}
+		if name != "" && name[0] != '/' {
+			return nil, p.errorf(tok, "json pointer must be empty or start with '/'")
+		}
+
+		return &Expr{Token: tok, Val: Node{Type: nodeText, Text: name}}, nil
+	case tokInput, tokNow:
+		p.advance()
+		return &Expr{Token: tok}, nil
+	default:
+		return nil, p.unexpected()
+	}
+}
+
+func (p *parser) list() (*Expr, error) {
+	x := &Expr{Token: p.tok}
+	x.Type = tokList
+
+	p.advance()
+
+	var items []Node
+
+	for p.tok.Type != tokListEnd {
+		if p.tok.Type == tokEOF {
+			return nil, p.errorf(x.Token, "unterminated list literal")
+		}
+		if p.tok.Type == tokBracketEnd && len(x.Args) == 2 {
+			x.Type, x.End = tokInterval, p.tok.End
+			x = p.interval(x, false, true)
+			p.advance()
+			return x, nil
+		}
+		if len(x.Args) > 0 {
+			if p.tok.Type != tokComma {
+				p.skip(bad(p.tok))
+				continue
+			}
+			p.advance()
+			if p.tok.Type == tokListEnd {
+				break
+			}
+		}
+
+		item := p.expr(0)
+
+		val, ok := constant(item)
+		if !ok {
+			p.fail(p.errorf(item.Token, "list items must be constant"))
+		}
+
+		x.Args = append(x.Args, item)
+		items = append(items, val)
+	}
+
+	x.End = p.tok.End
+	x.Val = Node{Type: nodeList, List: NewList(items...)}
+
+	p.advance()
+
+	return x, nil
+}
+
+func (p *parser) interval(x *Expr, loOpen, hiOpen bool) *Expr {
+	lo, lok := constant(x.Args[0])
+	hi, hok := constant(x.Args[1])
+
+	if !lok || !hok {
+		if x.Type == tokInterval {
+			p.fail(p.errorf(x.Token, "interval bounds must be constant"))
+		}
+		return x
+	}
+
+	val, err := RangeNode(lo, hi, loOpen, hiOpen)
+	if err != nil {
+		p.fail(p.errorf(x.Token, "%s", err))
+		return x
+	}
+
+	x.Type, x.Val = tokInterval, val
+
+	return x
+}
+
+func (p *parser) call(tok Token, name string, def funcDef) (*Expr, error) {
+	tok.Type = tokCall
+	x := &Expr{Token: tok, Val: Node{Type: nodeText, Text: name}}
+
+	p.advance()
+
+	for p.tok.Type != tokBracketEnd {
+		if p.tok.Type == tokEOF {
+			return nil, p.unexpected()
+		}
+		if len(x.Args) > 0 {
+			if p.tok.Type != tokComma {
+				p.skip(bad(p.tok))
+				continue
+			}
+			p.advance()
+		}
+
+		x.Args = append(x.Args, p.expr(0))
+	}
+
+	x.End = p.tok.End
+	p.advance()
+
+	if def.input && len(x.Args) == def.min-1 {
+		x.Args = append([]*Expr{input(tok)}, x.Args...)
+	}
+
+	if len(x.Args) < def.min || def.max >= 0 && len(x.Args) > def.max {
+		switch {
+		case def.min == def.max:
+			return nil, p.errorf(tok, "function %q expects %d args, got %d", name, def.min, len(x.Args))
+		case def.max < 0:
+			return nil, p.errorf(tok, "function %q expects at least %d args, got %d", name, def.min, len(x.Args))
+		default:
+			return nil, p.errorf(tok, "function %q expects %d to %d args, got %d", name, def.min, def.max, len(x.Args))
+		}
+	}
+
+	x.fn, x.sig = def.fn, def.sig
+
+	return x, nil
+}
+
+func (p *parser) regexp(x *Expr) error {
+	if x.Type != tokText {
+		return p.errorf(x.Token, "regular expression must be a text literal")
+	}
+
+	re, err := regexp.Compile(x.Val.Text)
+	if err != nil {
+		return p.errorf(x.Token, "invalid regular expression: %s", err)
+	}
+	x.Val.Re = re
+
+	return nil
+}
+
+func (p *parser) literal(tok Token) (*Expr, error) {
+	x := &Expr{Token: tok}
+
+	switch {
+	case p.big && tok.Type == tokInt:
+		val, err := parseBigInt(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode int: %s", err)
+		}
+		x.Val = val
+		return x, nil
+	case p.big && tok.Type == tokFloat:
+		val, err := parseBigFloat(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode float: %s", err)
+		}
+		x.Val = val
+		return x, nil
+	}
+
+	switch tok.Type {
+	case tokInt:
+		val, err := strconv.ParseInt(tok.repr(p.rule), 0, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode int: %s", err)
+		}
+		x.Val = Node{Type: nodeInt, Int: val}
+	case tokFloat:
+		val, err := strconv.ParseFloat(tok.repr(p.rule), 64)
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode float: %s", err)
+		}
+		x.Val = Node{Type: nodeFloat, Float: val}
+	case tokText:
+		val, err := unescape(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to unescape string: %s", err)
+		}
+		x.Val = Node{Type: nodeText, Text: val}
+	case tokTrue, tokFalse:
+		x.Val = Node{Type: nodeBool, Bool: tok.Type == tokTrue}
+	case tokNull:
+		x.Val = Node{Type: nodeNull}
+	case tokTime:
+		val, err := parseTime(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode time: %s", err)
+		}
+		x.Val = Node{Type: nodeTime, Time: val}
+	case tokDuration:
+		val, err := parseDuration(tok.repr(p.rule))
+		if err != nil {
+			return nil, p.errorf(tok, "failed to decode duration: %s", err)
+		}
+		x.Val = val
+	case tokWeekday:
+		x.Val = Node{Type: nodeInt, Int: weekdays[tok.repr(p.rule)]}
+	}
+
+	return x, nil
+}
+
+func input(tok Token) *Expr {
+	return &Expr{Token: Token{Type: tokInput, Start: tok.Start, End: tok.Start}}
+}
+
+func isBinaryOp(t TokenType) bool {
+	switch t {
+	case tokAND, tokOR, tokPlus, tokMinus, tokMultiply, tokDivide, tokModulo, tokPower, tokRange:
+		return true
+	case tokShiftLeft, tokShiftRight, tokBitAnd, tokBitOr, tokBitXor:
+		return true
+	}
+	return false
+}
+
+func constant(x *Expr) (Node, bool) {
+	if x.literal() {
+		return x.Val, true
+	}
+	if x.Type == tokNegate && x.Args[0].literal() {
+		if val := x.Args[0].Val; val.Big != nil {
+			return bigNode(new(big.Rat).Neg(val.Big), val.Type == nodeInt), true
+		}
+		switch val := x.Args[0].Val; val.Type {
+		case nodeInt:
+			return Node{Type: nodeInt, Int: -val.Int}, true
+		case nodeFloat:
+			return Node{Type: nodeFloat, Float: -val.Float}, true
+		case nodeDuration:
+			return Node{Type: nodeDuration, Int: -val.Int, Months: -val.Months}, true
+		}
+	}
+	return Node{}, false
+}
+
+func isCompareOp(t TokenType) bool {
+	switch t {
+	case tokEQ, tokNEQ, tokGT, tokGTE, tokLT, tokLTE, tokMatch, tokNotMatch, tokIn, tokNotIn:
+		return true
+	}
+	return false
+}
+
+func startsOperand(t TokenType) bool {
+	switch t {
+	case tokBang, tokRequired, tokOptional, tokBracketStart, tokListStart, tokInt, tokFloat, tokText, tokTrue, tokFalse, tokNull, tokTime, tokDuration, tokWeekday, tokNow, tokIdent, tokInput, tokPointer:
+		return true
+	}
+	return isCompareOp(t)
+}
@@ -0,0 +1,288 @@
+package boat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type object map[string]interface{}
+
+// queryBuilder builds the documents of a JSON query language.
+type queryBuilder interface {
+	String() string                                             // query language name
+	all(match bool) object                                      // matches everything, or nothing
+	and(a, b object) object                                     // both match
+	or(a, b object) object                                      // either matches
+	not(a object) object                                        // a does not match
+	exists(field string, exists bool) object                    // field is set and not null, or unset or null
+	compare(field string, op TokenType, val interface{}) object // field compared against val
+	match(field, re string) (object, bool)                      // field matches a regular expression, if supported
+	in(field string, vals []interface{}) object                 // field is one of vals
+	between(field string, r *Range, lo, hi interface{}) object  // field within a range
+	value(n Node) (interface{}, bool)                           // json value of n, if supported
+}
+
+type queryTranslator struct {
+	e      *Rule        // rule being translated
+	b      queryBuilder // target query language
+	fields []string     // fields in scope
+}
+
+func (e *Rule) query(field string, b queryBuilder) ([]byte, error) {
+	t := queryTranslator{e: e, b: b, fields: []string{field}}
+	q, err := t.test(e.root)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(q)
+}
+
+func (t *queryTranslator) unsupported(x *Expr, what string) error {
+	return unsupported(t.e.rule, t.b.String(), x, what)
+}
+
+func (t *queryTranslator) field() string {
+	return t.fields[len(t.fields)-1]
+}
+
+func (t *queryTranslator) test(x *Expr) (object, error) {
+	switch x.Type {
+	case tokAND, tokOR:
+		a, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := t.test(x.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokAND {
+			return t.b.and(a, b), nil
+		}
+		return t.b.or(a, b), nil
+	case tokBang:
+		a, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return t.b.not(a), nil
+	case tokSubject:
+		field, err := t.path(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		t.fields = append(t.fields, field)
+		q, err := t.test(x.Args[1])
+		t.fields = t.fields[:len(t.fields)-1]
+		return q, err
+	case tokRequired, tokOptional:
+		if x.Type == tokOptional && len(x.Args) == 0 {
+			return t.b.all(true), nil
+		}
+		exists := t.b.exists(t.field(), x.Type == tokRequired)
+		if len(x.Args) == 0 {
+			return exists, nil
+		}
+		q, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokRequired {
+			return t.b.and(exists, q), nil
+		}
+		return t.b.or(exists, q), nil
+	case tokEQ, tokNEQ, tokGT, tokGTE, tokLT, tokLTE:
+		return t.compare(x.Type, x.Args[0])
+	case tokMatch, tokNotMatch:
+		q, ok := t.b.match(t.field(), x.Args[0].Val.Text)
+		if !ok {
+			return nil, t.unsupported(x.Args[0], "this regular expression")
+		}
+		if x.Type == tokNotMatch {
+			return t.b.not(q), nil
+		}
+		return q, nil
+	case tokIn, tokNotIn:
+		q, err := t.member(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokNotIn {
+			return t.b.not(q), nil
+		}
+		return q, nil
+	}
+
+	val, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val.Type {
+	case nodeBool:
+		return t.b.all(val.Bool), nil
+	case nodeList, nodeRange:
+		return t.member(x)
+	}
+
+	return t.compare(tokEQ, x)
+}
+
+func (t *queryTranslator) path(x *Expr) (string, error) {
+	switch {
+	case x.Type == tokInput:
+		return t.field(), nil
+	case x.Type != tokIdent && x.Type != tokPointer:
+		return "", t.unsupported(x, "a subject that is not a field")
+	case x.Type == tokIdent || x.Val.Text == "":
+		return x.Val.Text, nil
+	}
+	parts := strings.Split(x.Val.Text[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func (t *queryTranslator) constant(x *Expr) (Node, error) {
+	val, ok, err := t.e.evalConst(x)
+	switch {
+	case err != nil:
+		return Node{}, fmt.Errorf("%s: %w", t.b, err)
+	case !ok:
+		return Node{}, t.unsupported(x, "a value that depends on the input")
+	}
+	return val, nil
+}
+
+func (t *queryTranslator) value(x *Expr, n Node) (interface{}, error) {
+	val, ok := t.b.value(n)
+	if !ok {
+		return nil, t.unsupported(x, "a "+n.Type.String()+" value")
+	}
+	return val, nil
+}
+
+func (t *queryTranslator) compare(op TokenType, x *Expr) (object, error) {
+	n, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+	if n.Type == nodeNull && (op == tokEQ || op == tokNEQ) {
+		return t.b.exists(t.field(), op == tokNEQ), nil
+	}
+	val, err := t.value(x, n)
+	if err != nil {
+		return nil, err
+	}
+	return t.b.compare(t.field(), op, val), nil
+}
+
+func (t *queryTranslator) member(x *Expr) (object, error) {
+	n, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Node{n}
+	switch n.Type {
+	case nodeList:
+		items = n.List.Items
+	case nodeRange:
+	default:
+		return nil, t.unsupported(x, "membership in a "+n.Type.String())
+	}
+
+	var qs []object
+	var vals []interface{}
+
+	for _, item := range items {
+		switch item.Type {
+		case nodeNull:
+			qs = append(qs, t.b.exists(t.field(), false))
+		case nodeRange:
+			lo, err := t.value(x, item.Range.Lo)
+			if err != nil {
+				return nil, err
+			}
+			hi, err := t.value(x, item.Range.Hi)
+			if err != nil {
+				return nil, err
+			}
+			qs = append(qs, t.b.between(t.field(), item.Range, lo, hi))
+		default:
+			val, err := t.value(x, item)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+	}
+
+	if len(vals) > 0 {
+		qs = append([]object{t.b.in(t.field(), vals)}, qs...)
+	}
+	if len(qs) == 0 {
+		return t.b.all(false), nil
+	}
+
+	q := qs[0]
+	for _, next := range qs[1:] {
+		q = t.b.or(q, next)
+	}
+	return q, nil
+}
+
+func jsonValue(n Node) (interface{}, bool) {
+	switch n.Type {
+	case nodeNull:
+		return nil, true
+	case nodeBool:
+		return n.Bool, true
+	case nodeInt:
+		return n.Int, true
+	case nodeFloat:
+		return n.Float, true
+	case nodeText:
+		return n.Text, true
+	}
+	return nil, false
+}
+
+// evalConst evaluates x if it does not depend on the input, reading now from
+// the rule's clock.
+func (e *Rule) evalConst(x *Expr) (Node, bool, error) {
+	switch {
+	case x.literal():
+		return x.Val, true, nil
+	case x.Type == tokNow:
+		return Node{Type: nodeTime, Time: e.now()}, true, nil
+	case x.Type == tokCall, x.Type == tokNegate, x.Type == tokBitNot:
+	case !isBinaryOp(x.Type), x.Type == tokAND, x.Type == tokOR:
+		return Node{}, false, nil
+	}
+
+	args := make([]Node, len(x.Args))
+	for i, arg := range x.Args {
+		val, ok, err := e.evalConst(arg)
+		if !ok || err != nil {
+			return Node{}, false, err
+		}
+		args[i] = val
+	}
+
+	if x.Type == tokCall {
+		val, err := x.fn(args...)
+		if err != nil {
+			return Node{}, false, fmt.Errorf("error calling %s: %w", x.Val.Text, err)
+		}
+		return val, true, nil
+	}
+
+	r := Rule{big: e.big, overflow: e.overflow, vals: args}
+	if err := r.EvalOP(Node{}, x.Type); err != nil {
+		return Node{}, false, err
+	}
+	return r.vals[0], true, nil
+}
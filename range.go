@@ -0,0 +1,57 @@
+package boat
+
+import "fmt"
+
+type Range struct {
+	Lo     Node // lower bound
+	Hi     Node // upper bound
+	LoOpen bool // lower bound excluded?
+	HiOpen bool // upper bound excluded?
+}
+
+func NewRange(lo, hi Node, loOpen, hiOpen bool) (*Range, error) {
+	c, ok := Compare(lo, hi)
+	if !ok {
+		return nil, fmt.Errorf("range bounds must be ordered values of the same kind, got %s and %s", lo.Type, hi.Type)
+	}
+	if c > 0 {
+		return nil, fmt.Errorf("range lower bound %s is greater than upper bound %s", lo, hi)
+	}
+	return &Range{Lo: lo, Hi: hi, LoOpen: loOpen, HiOpen: hiOpen}, nil
+}
+
+func RangeNode(lo, hi Node, loOpen, hiOpen bool) (Node, error) {
+	r, err := NewRange(lo, hi, loOpen, hiOpen)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{Type: nodeRange, Range: r}, nil
+}
+
+func (r *Range) Contains(n Node) bool {
+	lo, ok := Compare(n, r.Lo)
+	if !ok || lo < 0 || lo == 0 && r.LoOpen {
+		return false
+	}
+	hi, ok := Compare(n, r.Hi)
+	if !ok || hi > 0 || hi == 0 && r.HiOpen {
+		return false
+	}
+	return true
+}
+
+func (r *Range) String() string {
+	if !r.LoOpen && !r.HiOpen {
+		return r.Lo.String() + ".." + r.Hi.String()
+	}
+
+	lo, hi := "[", "]"
+	if r.LoOpen {
+		lo = "("
+	}
+	if r.HiOpen {
+		hi = ")"
+	}
+
+	return lo + r.Lo.String() + ", " + r.Hi.String() + hi
+}
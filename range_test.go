@@ -0,0 +1,83 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRangeRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "1", rule: `1..400`, pass: true},
+		{in: "400", rule: `1..400`, pass: true},
+		{in: "401", rule: `1..400`, pass: false},
+		{in: "0.5", rule: `1..400`, pass: false},
+		{in: "550", rule: `1..400 | 500..600`, pass: true},
+		{in: "450", rule: `1..400 | 500..600`, pass: false},
+		{in: "1", rule: `in [1, 400)`, pass: true},
+		{in: "400", rule: `in [1, 400)`, pass: false},
+		{in: "1", rule: `in (1, 400]`, pass: false},
+		{in: "400", rule: `in (1, 400]`, pass: true},
+		{in: "1.0001", rule: `(1, 400)`, pass: true},
+		{in: "2.5", rule: `in 1.5..2.5`, pass: true},
+		{in: "2", rule: `in 1.5..2.5`, pass: true},
+		{in: "-3", rule: `-5..-1`, pass: true},
+		{in: "1", rule: `1+1..5`, pass: false},
+		{in: "7", rule: `in [1..5, 7, 10..20]`, pass: true},
+		{in: "15", rule: `in [1..5, 7, 10..20]`, pass: true},
+		{in: "8", rule: `in [1..5, 7, 10..20]`, pass: false},
+		{in: "8", rule: `not in 1..5`, pass: true},
+		{in: "abc", rule: `1..5`, pass: false},
+		{in: "abc", rule: `len($) in 1..3`, pass: true},
+		{in: "3", rule: `$ == 1..5`, pass: false},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestRangeEnv(t *testing.T) {
+	px, err := ParseRule(`age in min..max & age + 1 in min..max`)
+	require.NoError(t, err)
+
+	pass, err := px.EvalEnv("", Env{"age": IntNode(17), "min": IntNode(13), "max": FloatNode(17.5)})
+	require.NoError(t, err)
+	require.False(t, pass)
+
+	pass, err = px.EvalEnv("", Env{"age": IntNode(16), "min": IntNode(13), "max": FloatNode(17.5)})
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	_, err = px.EvalEnv("", Env{"age": IntNode(16), "min": IntNode(20), "max": IntNode(10)})
+	require.Error(t, err)
+
+	_, err = px.EvalEnv("", Env{"age": IntNode(16), "min": TextNode("a"), "max": IntNode(10)})
+	require.Error(t, err)
+}
+
+func TestRangeErrors(t *testing.T) {
+	cases := []string{
+		`5..1`,
+		`"a".."z"`,
+		`(1, len($))`,
+		`[1, 2, 3)`,
+		`(1, 2`,
+		`(1, 2, 3)`,
+		`[5, 1)`,
+		`..5`,
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test)
+		require.Error(t, err, test)
+	}
+}
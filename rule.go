@@ -3,8 +3,9 @@ package boat
 import (
 	"errors"
 	"fmt"
-	"strconv"
+	"math/big"
 	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -12,279 +13,225 @@ var Ops = [...]struct {
 	prec int  // precedence
 	rtl  bool // right-associative?
 }{
-	tokNegate: {prec: 6, rtl: true},
-
-	tokMultiply: {prec: 5},
-	tokDivide:   {prec: 5},
-
-	tokPlus:  {prec: 4},
-	tokMinus: {prec: 4},
-
-	tokBang: {prec: 3, rtl: true},
-	tokGT:   {prec: 3, rtl: true},
-	tokGTE:  {prec: 3, rtl: true},
-	tokLT:   {prec: 3, rtl: true},
-	tokLTE:  {prec: 3, rtl: true},
+	tokPower: {prec: 8, rtl: true},
+
+	tokNegate: {prec: 7, rtl: true},
+	tokBitNot: {prec: 7, rtl: true},
+
+	tokMultiply:   {prec: 6},
+	tokDivide:     {prec: 6},
+	tokModulo:     {prec: 6},
+	tokShiftLeft:  {prec: 6},
+	tokShiftRight: {prec: 6},
+	tokBitAnd:     {prec: 6},
+
+	tokPlus:   {prec: 5},
+	tokMinus:  {prec: 5},
+	tokBitOr:  {prec: 5},
+	tokBitXor: {prec: 5},
+
+	tokRange: {prec: 4},
+
+	tokBang:     {prec: 3, rtl: true},
+	tokEQ:       {prec: 3, rtl: true},
+	tokNEQ:      {prec: 3, rtl: true},
+	tokMatch:    {prec: 3, rtl: true},
+	tokNotMatch: {prec: 3, rtl: true},
+	tokIn:       {prec: 3, rtl: true},
+	tokNotIn:    {prec: 3, rtl: true},
+	tokGT:       {prec: 3, rtl: true},
+	tokGTE:      {prec: 3, rtl: true},
+	tokLT:       {prec: 3, rtl: true},
+	tokLTE:      {prec: 3, rtl: true},
+
+	tokSubject: {prec: 3, rtl: true},
 
 	tokAND: {prec: 2},
 	tokOR:  {prec: 1},
-}
 
-type Rule struct {
-	rule string  // rule
-	buf  []Token // tokens
-	ops  []Token // stack of ops
-	vals []Node  // stack of vals
+	tokRequired: {prec: 0, rtl: true},
+	tokOptional: {prec: 0, rtl: true},
 }
 
-func ParseRuleBytes(buf []byte) (Rule, error) {
-	return ParseRule(*(*string)(unsafe.Pointer(&buf)))
+type Rule struct {
+	rule     string           // rule
+	funcs    *Registry        // function registry
+	clock    func() time.Time // clock used by now
+	big      bool             // big number mode?
+	overflow OverflowPolicy   // int overflow policy
+	accepts  InputKind        // accepted input kinds
+	root     *Expr            // expression tree
+	code     []instr          // compiled program
+	exprs    []*Expr          // source expr of each instruction
+	consts   []Node           // constant pool
+	calls    []call           // called funcs
+	vals     []Node           // stack of vals
+	ins      []Node           // stack of inputs
 }
 
-func ParseRule(rule string) (Rule, error) {
-	r := Rule{rule: rule, ops: make([]Token, 0, 16), vals: make([]Node, 0, 16)}
+type Option func(*Rule)
 
-	m := NewMachine(rule)
+func WithRegistry(reg *Registry) Option {
+	return func(r *Rule) {
+		r.funcs = reg
+	}
+}
 
-	tok := m.Next()
-	for tok.Type != tokEOF && tok.Type != tokError {
-		r.buf = append(r.buf, tok)
-		tok = m.Next()
+func WithClock(clock func() time.Time) Option {
+	return func(r *Rule) {
+		r.clock = clock
 	}
+}
 
-	if tok.Type == tokError {
-		return r, fmt.Errorf("%d:%d error parsing rule: %s", tok.Start, tok.End, m.err)
+func WithBigNumbers() Option {
+	return func(r *Rule) {
+		r.big = true
 	}
+}
 
-	return r, nil
+func ParseRuleBytes(buf []byte, opts ...Option) (Rule, error) {
+	return ParseRule(*(*string)(unsafe.Pointer(&buf)), opts...)
 }
 
-func (e *Rule) Eval(input string) (bool, error) {
-	in, err := Decode(input)
+func ParseRule(rule string, opts ...Option) (Rule, error) {
+	r := Rule{rule: rule}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	root, err := parse(rule, r.funcs, r.big)
 	if err != nil {
-		return false, err
+		return r, err
 	}
+	r.root = root
 
-	e.ops = e.ops[:0]
-	e.vals = e.vals[:0]
+	if err = check(rule, root); err != nil {
+		return r, ErrorList{err.(*SyntaxError)}
+	}
 
-	for i := 0; i < len(e.buf); i++ {
-		c := e.buf[i]
-		switch c.Type {
-		case tokInt:
-			val, err := strconv.ParseInt(c.repr(e.rule), 0, 64)
-			if err != nil {
-				return false, fmt.Errorf("failed to decode int: %w", err)
-			}
-			e.vals = append(e.vals, Node{Type: nodeInt, Int: val})
-		case tokFloat:
-			val, err := strconv.ParseFloat(c.repr(e.rule), 64)
-			if err != nil {
-				return false, fmt.Errorf("failed to decode float: %w", err)
-			}
-			e.vals = append(e.vals, Node{Type: nodeFloat, Float: val})
-		case tokText:
-			val, err := unescape(c.repr(e.rule))
-			if err != nil {
-				return false, fmt.Errorf("failed to unescape string: %w", err)
-			}
-			e.vals = append(e.vals, Node{Type: nodeText, Text: val})
-		case tokBracketStart:
-			e.ops = append(e.ops, c)
-		case tokBracketEnd:
-			for len(e.ops) > 0 {
-				op := e.ops[len(e.ops)-1]
-				e.ops = e.ops[:len(e.ops)-1]
-
-				if op.Type == tokBracketStart {
-					break
-				}
+	if r.root, err = r.fold(root); err != nil {
+		return r, ErrorList{err.(*SyntaxError)}
+	}
 
-				if err := e.EvalOP(in, op); err != nil {
-					return false, fmt.Errorf("error while evaluating op input brackets: %w", err)
-				}
-			}
-		case tokGT, tokGTE, tokLT, tokLTE, tokBang, tokAND, tokOR, tokPlus, tokMinus, tokMultiply, tokDivide:
-			if c.Type == tokMinus {
-				if i == 0 {
-					c.Type = tokNegate
-				} else {
-					l := e.buf[i-1]
-					if l.Type != tokInt && l.Type != tokFloat && l.Type != tokText {
-						c.Type = tokNegate
-					}
-				}
-			}
+	r.accepts = accepts(rule, r.root)
 
-			for len(e.ops) > 0 {
-				op := e.ops[len(e.ops)-1]
+	r.compile(r.root)
 
-				if op.Type == tokBracketStart {
-					break
-				}
+	return r, nil
+}
 
-				o1 := Ops[c.Type]
-				o2 := Ops[op.Type]
+func (e *Rule) compile(root *Expr) {
+	c := compile(root)
+	e.code, e.exprs, e.consts, e.calls = c.code, c.exprs, c.consts, c.calls
+	e.vals, e.ins = make([]Node, 0, c.max), make([]Node, 0, c.scopes)
+}
 
-				if o1.prec > o2.prec || o1.prec == o2.prec && o1.rtl {
-					break
-				}
+func (e *Rule) Accepts() InputKind {
+	return e.accepts
+}
 
-				e.ops = e.ops[:len(e.ops)-1]
+func (e *Rule) Eval(input string) (bool, error) {
+	return e.EvalEnv(input, nil)
+}
 
-				if err := e.EvalOP(in, op); err != nil {
-					return false, fmt.Errorf("error while evaluating op: %w", err)
-				}
-			}
-			e.ops = append(e.ops, c)
-		}
+func (e *Rule) EvalEnv(input string, env Resolver) (bool, error) {
+	in, err := decode(input, e.big)
+	if err != nil {
+		return false, err
 	}
+	return e.eval(in, env)
+}
 
-	for len(e.ops) > 0 {
-		op := e.ops[len(e.ops)-1]
-		e.ops = e.ops[:len(e.ops)-1]
-
-		if op.Type == tokBracketStart {
-			return false, errors.New("mismatched parenthesis")
+func (e *Rule) eval(in Node, env Resolver) (pass bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pass, err = false, fmt.Errorf("%w: %v", ErrPanic, r)
 		}
+	}()
 
-		if err := e.EvalOP(in, op); err != nil {
-			return false, fmt.Errorf("error while evaluating op: %w", err)
-		}
+	if err := e.run(in, env); err != nil {
+		return false, err
 	}
 
 	if len(e.vals) != 1 {
 		return false, fmt.Errorf("got %d values from evaluating the rule: expected only one", len(e.vals))
 	}
 
-	return EvalNode(in, e.vals[0]), nil
+	return e.vals[0].Bool, nil
 }
 
-func (e *Rule) EvalOP(in Node, op Token) error {
-	//fmt.Printf("EVAL %q\n", op.repr(e.rule))
+func (e *Rule) EvalOP(in Node, op TokenType) error {
+	switch op {
+	case tokPlus, tokMinus, tokMultiply, tokDivide, tokModulo, tokPower, tokShiftLeft, tokShiftRight, tokBitAnd, tokBitOr, tokBitXor:
+		if n := len(e.vals); n >= 2 && isBig(e.vals[n-2], e.vals[n-1]) {
+			val, err := bigArith(op, e.vals[n-2], e.vals[n-1])
+			if err != nil {
+				return err
+			}
+			e.vals[n-2], e.vals = val, e.vals[:n-1]
+			return nil
+		}
+	}
 
-	switch op.Type {
+	switch op {
 	case tokNegate:
 		if len(e.vals) < 1 {
 			return errors.New(`unary '-' must have a rhs that is an int or float`)
 		}
 		i := len(e.vals) - 1
-		switch e.vals[i].Type {
-		case nodeInt:
-			e.vals[i].Int = -e.vals[i].Int
-		case nodeFloat:
-			e.vals[i].Float = -e.vals[i].Float
-		default:
-			return errors.New(`unary '-' not paired with int or float`)
-		}
-	case tokGT:
-		if len(e.vals) < 1 {
-			return errors.New(`'>' must have a rhs that is an int or float`)
+		if e.vals[i].Big != nil {
+			e.vals[i] = bigNode(new(big.Rat).Neg(e.vals[i].Big), e.vals[i].Type == nodeInt)
+			break
 		}
-		i := len(e.vals) - 1
 		switch e.vals[i].Type {
 		case nodeInt:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Int > e.vals[i].Int}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float > float64(e.vals[i].Int)}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
+			x, err := e.negInt(e.vals[i].Int)
+			if err != nil {
+				return err
 			}
+			e.vals[i].Int = x
 		case nodeFloat:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: float64(in.Int) > e.vals[i].Float}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float > e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
+			e.vals[i].Float = -e.vals[i].Float
+		case nodeDuration:
+			e.vals[i].Int, e.vals[i].Months = -e.vals[i].Int, -e.vals[i].Months
 		default:
-			return errors.New(`'>' not paired with int or float`)
+			return errors.New(`unary '-' not paired with int, float or duration`)
 		}
-	case tokLT:
+	case tokGT, tokGTE, tokLT, tokLTE:
 		if len(e.vals) < 1 {
-			return errors.New(`'<' must have a rhs that is an int or float`)
+			return fmt.Errorf(`'%s' must have a rhs that is an int or float`, op)
 		}
 		i := len(e.vals) - 1
 		switch e.vals[i].Type {
-		case nodeInt:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Int < e.vals[i].Int}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float < float64(e.vals[i].Int)}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
-		case nodeFloat:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: float64(in.Int) < e.vals[i].Float}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float < e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
+		case nodeInt, nodeFloat, nodeTime, nodeDuration:
 		default:
-			return errors.New(`'<' not paired with int or float`)
+			return fmt.Errorf(`'%s' not paired with int, float, time or duration`, op)
 		}
-	case tokGTE:
-		if len(e.vals) < 1 {
-			return errors.New(`'>=' must have a rhs that is an int or float`)
-		}
-		i := len(e.vals) - 1
-		switch e.vals[i].Type {
-		case nodeInt:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Int >= e.vals[i].Int}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float >= float64(e.vals[i].Int)}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
-		case nodeFloat:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: float64(in.Int) >= e.vals[i].Float}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float >= e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
-		default:
-			return errors.New(`'>=' not paired with int or float`)
+		c, ok := Compare(in, e.vals[i])
+		switch op {
+		case tokGT:
+			ok = ok && c > 0
+		case tokGTE:
+			ok = ok && c >= 0
+		case tokLT:
+			ok = ok && c < 0
+		case tokLTE:
+			ok = ok && c <= 0
 		}
-	case tokLTE:
-		if len(e.vals) < 1 {
-			return errors.New(`'<=' must have a rhs that is an int or float`)
+		e.vals[i] = Node{Type: nodeBool, Bool: ok}
+	case tokRange:
+		if len(e.vals) < 2 {
+			return errors.New(`'..' requires a lhs and rhs that is an int or float`)
 		}
-		i := len(e.vals) - 1
-		switch e.vals[i].Type {
-		case nodeInt:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Int <= e.vals[i].Int}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: float64(in.Int) <= e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
-		case nodeFloat:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float <= float64(e.vals[i].Int)}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float <= e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: false}
-			}
-		default:
-			return errors.New(`'<=' not paired with int or float`)
+		l := len(e.vals) - 2
+		r := l + 1
+		val, err := RangeNode(e.vals[l], e.vals[r], false, false)
+		if err != nil {
+			return err
 		}
+		e.vals[l] = val
+		e.vals = e.vals[:r]
 	case tokPlus:
 		if len(e.vals) < 2 {
 			return errors.New(`'+' requires a lhs and rhs that is an string/int/float`)
@@ -295,7 +242,11 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 		case nodeInt:
 			switch e.vals[r].Type {
 			case nodeInt:
-				e.vals[l] = Node{Type: nodeInt, Int: e.vals[l].Int + e.vals[r].Int}
+				x, err := e.addInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeInt, Int: x}
 			case nodeFloat:
 				e.vals[l] = Node{Type: nodeFloat, Float: float64(e.vals[l].Int) + e.vals[r].Float}
 			default:
@@ -321,6 +272,26 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 			default:
 				return errors.New(`lhs is string, rhs for '+' must be a string`)
 			}
+		case nodeTime:
+			switch e.vals[r].Type {
+			case nodeDuration:
+				e.vals[l] = Node{Type: nodeTime, Time: addDuration(e.vals[l].Time, e.vals[r], 1)}
+			default:
+				return errors.New(`lhs is time, rhs for '+' must be a duration`)
+			}
+		case nodeDuration:
+			switch e.vals[r].Type {
+			case nodeDuration:
+				x, err := e.addInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeDuration, Int: x, Months: e.vals[l].Months + e.vals[r].Months}
+			case nodeTime:
+				e.vals[l] = Node{Type: nodeTime, Time: addDuration(e.vals[r].Time, e.vals[l], 1)}
+			default:
+				return errors.New(`lhs is duration, rhs for '+' must be a duration or time`)
+			}
 		default:
 			return errors.New("lhs and rhs for '+' must be int or float")
 		}
@@ -335,7 +306,11 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 		case nodeInt:
 			switch e.vals[r].Type {
 			case nodeInt:
-				e.vals[l] = Node{Type: nodeInt, Int: e.vals[l].Int - e.vals[r].Int}
+				x, err := e.subInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeInt, Int: x}
 			case nodeFloat:
 				e.vals[l] = Node{Type: nodeFloat, Float: float64(e.vals[l].Int) - e.vals[r].Float}
 			default:
@@ -350,6 +325,26 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 			default:
 				return errors.New(`lhs is float, rhs for '-' must be an int or float`)
 			}
+		case nodeTime:
+			switch e.vals[r].Type {
+			case nodeDuration:
+				e.vals[l] = Node{Type: nodeTime, Time: addDuration(e.vals[l].Time, e.vals[r], -1)}
+			case nodeTime:
+				e.vals[l] = Node{Type: nodeDuration, Int: int64(e.vals[l].Time.Sub(e.vals[r].Time))}
+			default:
+				return errors.New(`lhs is time, rhs for '-' must be a duration or time`)
+			}
+		case nodeDuration:
+			switch e.vals[r].Type {
+			case nodeDuration:
+				x, err := e.subInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeDuration, Int: x, Months: e.vals[l].Months - e.vals[r].Months}
+			default:
+				return errors.New(`lhs is duration, rhs for '-' must be a duration`)
+			}
 		default:
 			return errors.New(`lhs and rhs for '-' must be int or float`)
 		}
@@ -364,7 +359,11 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 		case nodeInt:
 			switch e.vals[r].Type {
 			case nodeInt:
-				e.vals[l] = Node{Type: nodeInt, Int: e.vals[l].Int * e.vals[r].Int}
+				x, err := e.mulInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeInt, Int: x}
 			case nodeFloat:
 				e.vals[l] = Node{Type: nodeFloat, Float: float64(e.vals[l].Int) * e.vals[r].Float}
 			default:
@@ -382,7 +381,14 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 		case nodeText:
 			switch e.vals[r].Type {
 			case nodeInt:
-				e.vals[l] = Node{Type: nodeText, Text: strings.Repeat(e.vals[l].Text, int(e.vals[r].Int))}
+				if e.vals[r].Big != nil && !e.vals[r].Big.Num().IsInt64() {
+					return fmt.Errorf(`'*': %w`, ErrRepeatCount)
+				}
+				text, err := repeat(e.vals[l].Text, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeText, Text: text}
 			default:
 				return errors.New(`lhs is string, rhs for '*' must be an int`)
 			}
@@ -400,7 +406,11 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 		case nodeInt:
 			switch e.vals[r].Type {
 			case nodeInt:
-				e.vals[l] = Node{Type: nodeInt, Int: e.vals[l].Int / e.vals[r].Int}
+				x, err := e.divInt(e.vals[l].Int, e.vals[r].Int)
+				if err != nil {
+					return err
+				}
+				e.vals[l] = Node{Type: nodeInt, Int: x}
 			case nodeFloat:
 				e.vals[l] = Node{Type: nodeFloat, Float: float64(e.vals[l].Int) / e.vals[r].Float}
 			default:
@@ -419,40 +429,62 @@ func (e *Rule) EvalOP(in Node, op Token) error {
 			return errors.New(`lhs and rhs for '/' must be int or float`)
 		}
 		e.vals = e.vals[:r]
+	case tokModulo, tokPower, tokShiftLeft, tokShiftRight, tokBitAnd, tokBitOr, tokBitXor:
+		if len(e.vals) < 2 {
+			return fmt.Errorf(`'%s' requires a lhs and rhs that is an int or float`, op)
+		}
+		l := len(e.vals) - 2
+		val, err := e.evalIntOP(op, e.vals[l], e.vals[l+1])
+		if err != nil {
+			return err
+		}
+		e.vals[l], e.vals = val, e.vals[:l+1]
+	case tokBitNot:
+		if len(e.vals) < 1 {
+			return errors.New(`'~~~' must have a rhs that is an int`)
+		}
+		i := len(e.vals) - 1
+		switch {
+		case e.vals[i].Big != nil && e.vals[i].Type == nodeInt:
+			e.vals[i] = bigNode(new(big.Rat).SetInt(new(big.Int).Not(e.vals[i].Big.Num())), true)
+		case e.vals[i].Type == nodeInt:
+			e.vals[i].Int = ^e.vals[i].Int
+		default:
+			return errors.New(`'~~~' not paired with int`)
+		}
 	case tokBang:
 		if len(e.vals) < 1 {
 			return errors.New(`'!' requires a rhs that is a string/bool/int/float`)
 		}
 		i := len(e.vals) - 1
+		e.vals[i] = Node{Type: nodeBool, Bool: !EvalNode(in, e.vals[i])}
+	case tokEQ, tokNEQ:
+		if len(e.vals) < 1 {
+			return fmt.Errorf(`'%s' requires a rhs that is a string/bool/int/float`, op)
+		}
+		i := len(e.vals) - 1
+		e.vals[i] = Node{Type: nodeBool, Bool: Equal(in, e.vals[i]) == (op == tokEQ)}
+	case tokMatch, tokNotMatch:
+		if len(e.vals) < 1 {
+			return fmt.Errorf(`'%s' requires a rhs that is a regular expression`, op)
+		}
+		i := len(e.vals) - 1
+		if e.vals[i].Re == nil {
+			return fmt.Errorf(`'%s' not paired with a regular expression`, op)
+		}
+		match := in.Type == nodeText && e.vals[i].Re.MatchString(in.Text)
+		e.vals[i] = Node{Type: nodeBool, Bool: match == (op == tokMatch)}
+	case tokIn, tokNotIn:
+		if len(e.vals) < 1 {
+			return fmt.Errorf(`'%s' requires a rhs that is a list or range`, op)
+		}
+		i := len(e.vals) - 1
 		switch e.vals[i].Type {
-		case nodeText:
-			switch in.Type {
-			case nodeText:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Text != e.vals[i].Text}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: true}
-			}
-		case nodeBool:
-			e.vals[i] = Node{Type: nodeBool, Bool: !e.vals[i].Bool}
-		case nodeInt:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Int != e.vals[i].Int}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float != float64(e.vals[i].Int)}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: true}
-			}
-		case nodeFloat:
-			switch in.Type {
-			case nodeInt:
-				e.vals[i] = Node{Type: nodeBool, Bool: float64(in.Int) != e.vals[i].Float}
-			case nodeFloat:
-				e.vals[i] = Node{Type: nodeBool, Bool: in.Float != e.vals[i].Float}
-			default:
-				e.vals[i] = Node{Type: nodeBool, Bool: true}
-			}
+		case nodeList, nodeRange:
+		default:
+			return fmt.Errorf(`'%s' not paired with a list or range`, op)
 		}
+		e.vals[i] = Node{Type: nodeBool, Bool: EvalNode(in, e.vals[i]) == (op == tokIn)}
 	case tokAND:
 		if len(e.vals) < 2 {
 			return errors.New(`'&' requires a lhs and rhs that is a string/bool/int/float`)
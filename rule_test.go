@@ -18,12 +18,32 @@ func TestInvalidRules(t *testing.T) {
 	}
 
 	for _, test := range cases {
-		px, err := ParseRule(test)
-		if err != nil {
-			continue
-		}
-		_, err = px.Eval("invalid")
-		require.Error(t, err)
+		_, err := ParseRule(test)
+		require.Error(t, err, test)
+	}
+}
+
+func TestRuleSyntaxErrors(t *testing.T) {
+	cases := []string{
+		``,
+		`"hello" ++`,
+		`123 -+ 4`,
+		`123 456`,
+		`(1 + 2`,
+		`1 + 2)`,
+		`((>=1) & <=5`,
+		`!`,
+		`"hello world`,
+		`0xfg`,
+		`~ 123`,
+		`~ "[a-z"`,
+		`~ "a" + "b"`,
+		`!~`,
+	}
+
+	for _, test := range cases {
+		_, err := ParseRule(test)
+		require.Error(t, err, test)
 	}
 }
 
@@ -48,6 +68,38 @@ func TestRules(t *testing.T) {
 		{in: "hehehe", rule: `"he" * 3`, pass: true},
 		{in: "hello\nworld\test", rule: `"hello\nworld\test"`, pass: true},
 		{in: "\377 test \u2847 \xff", rule: `"\377 test \u2847 \xff"`, pass: true},
+		{in: "hello", rule: `~ "^[a-z]+$"`, pass: true},
+		{in: "Hello", rule: `~ "^[a-z]+$"`, pass: false},
+		{in: "Hello", rule: `!~ "^[a-z]+$"`, pass: true},
+		{in: "123", rule: `~ "^[0-9]+$"`, pass: false},
+		{in: "123", rule: `!~ "^[0-9]+$"`, pass: true},
+		{in: "3", rule: `== 3`, pass: true},
+		{in: "3.0", rule: `= 3`, pass: true},
+		{in: "3", rule: `!= 3`, pass: false},
+		{in: "abc", rule: `!= 3 & != "abd"`, pass: true},
+		{in: "3", rule: `!(>=1 & <=5) & != 3`, pass: false},
+		{in: "7", rule: `!(>=1 & <=5) & != 3`, pass: true},
+		{in: "4", rule: `!(>=1 & <=5) & != 3`, pass: false},
+		{in: "3", rule: `!!3`, pass: true},
+		{in: "", rule: `null`, pass: true},
+		{in: "", rule: `== null`, pass: true},
+		{in: "", rule: `""`, pass: false},
+		{in: "0", rule: `!= null`, pass: true},
+		{in: "true", rule: `true`, pass: true},
+		{in: "true", rule: `== true`, pass: true},
+		{in: "false", rule: `== false`, pass: true},
+		{in: "false", rule: `false`, pass: false},
+		{in: "false", rule: `!= true & != "false"`, pass: true},
+		{in: "", rule: `optional >=1 & <=5`, pass: true},
+		{in: "3", rule: `optional >=1 & <=5`, pass: true},
+		{in: "7", rule: `optional >=1 & <=5`, pass: false},
+		{in: "", rule: `required >=1 & <=5`, pass: false},
+		{in: "3", rule: `required >=1 & <=5`, pass: true},
+		{in: "", rule: `optional`, pass: true},
+		{in: "", rule: `required`, pass: false},
+		{in: "x", rule: `required`, pass: true},
+		{in: "", rule: `required | optional`, pass: true},
+		{in: "abc-123", rule: `~ "^[a-z]+" & ~ "[0-9]+$" & !~ "_"`, pass: true},
 	}
 
 	for _, test := range cases {
@@ -81,3 +133,18 @@ func BenchmarkRule(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkLargeRule(b *testing.B) {
+	px, err := ParseRule(`!(>=1 & <=400 | >=500 & <=600) & (<(1+2)*3 | >=100/2 & <1000 | "he" * 3 | "hello " + "world")`)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pass, err := px.Eval(`450`)
+		if !pass || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,11 +1,20 @@
 package boat
 
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
 const whitespace = uint64(1<<'\t' | 1<<'\n' | 1<<'\r' | 1<<' ')
 
 func isWhitespace(r rune) bool {
 	return whitespace&(1<<uint(r)) != 0
 }
 
+func isIdentRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= utf8.RuneSelf && unicode.IsLetter(r)
+}
+
 func isBinRune(r rune) bool {
 	return r == '0' || r == '1'
 }
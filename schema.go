@@ -0,0 +1,305 @@
+package boat
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var schemaOps = [...]string{
+	tokGT:  "exclusiveMinimum",
+	tokGTE: "minimum",
+	tokLT:  "exclusiveMaximum",
+	tokLTE: "maximum",
+}
+
+// JSONSchema translates the rule into a JSON Schema fragment that accepts the
+// same documents as EvalJSON.
+func (e *Rule) JSONSchema() ([]byte, error) {
+	t := schemaTranslator{e: e}
+	s, err := t.test(e.root)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+type schemaTranslator struct {
+	e      *Rule // rule being translated
+	nested bool  // inside a subject?
+}
+
+func (t *schemaTranslator) unsupported(x *Expr, what string) error {
+	return unsupported(t.e.rule, "jsonschema", x, what)
+}
+
+func (t *schemaTranslator) test(x *Expr) (object, error) {
+	switch x.Type {
+	case tokAND, tokOR:
+		a, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := t.test(x.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokAND {
+			return t.allOf(a, b), nil
+		}
+		return t.anyOf(a, b), nil
+	case tokBang:
+		a, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return object{"not": a}, nil
+	case tokSubject:
+		return t.subject(x)
+	case tokRequired, tokOptional:
+		if x.Type == tokOptional && len(x.Args) == 0 {
+			return object{}, nil
+		}
+		null := object{"type": "null"}
+		if len(x.Args) == 0 {
+			return object{"not": null}, nil
+		}
+		s, err := t.test(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokRequired {
+			return t.allOf(object{"not": null}, s), nil
+		}
+		return t.anyOf(null, s), nil
+	case tokEQ, tokNEQ, tokGT, tokGTE, tokLT, tokLTE:
+		return t.compare(x.Type, x.Args[0])
+	case tokMatch, tokNotMatch:
+		s := object{"type": "string", "pattern": x.Args[0].Val.Text}
+		if x.Type == tokNotMatch {
+			return object{"not": s}, nil
+		}
+		return s, nil
+	case tokIn, tokNotIn:
+		s, err := t.member(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if x.Type == tokNotIn {
+			return object{"not": s}, nil
+		}
+		return s, nil
+	}
+
+	val, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val.Type {
+	case nodeBool:
+		if val.Bool {
+			return object{}, nil
+		}
+		return object{"not": object{}}, nil
+	case nodeList, nodeRange:
+		return t.member(x)
+	}
+
+	return t.compare(tokEQ, x)
+}
+
+// subject places the schema of a subject test under the properties of the
+// field it names, which is required unless the test passes on null.
+func (t *schemaTranslator) subject(x *Expr) (object, error) {
+	var path []string
+	switch lhs := x.Args[0]; {
+	case lhs.Type == tokInput:
+		return t.test(x.Args[1])
+	case lhs.Type != tokIdent && lhs.Type != tokPointer:
+		return nil, t.unsupported(lhs, "a subject that is not a field")
+	case t.nested:
+		return nil, t.unsupported(lhs, "a field inside a subject")
+	default:
+		path = splitPath(lhs.Val.Text)
+	}
+
+	t.nested = true
+	s, err := t.test(x.Args[1])
+	t.nested = false
+	if err != nil {
+		return nil, err
+	}
+
+	required := !t.passesNull(x.Args[1])
+	for i := len(path) - 1; i >= 0; i-- {
+		s = object{"properties": object{path[i]: s}}
+		if required {
+			s["required"] = []string{path[i]}
+		}
+	}
+	return s, nil
+}
+
+// passesNull reports whether x passes on a null input, which is what a field
+// that is missing from the document resolves to.
+func (t *schemaTranslator) passesNull(x *Expr) bool {
+	r := *t.e
+	r.compile(x)
+	pass, err := r.eval(Node{Type: nodeNull}, nil)
+	return err == nil && pass
+}
+
+func (t *schemaTranslator) constant(x *Expr) (Node, error) {
+	val, ok, err := t.e.evalConst(x)
+	switch {
+	case err != nil:
+		return Node{}, fmt.Errorf("jsonschema: %w", err)
+	case !ok:
+		return Node{}, t.unsupported(x, "a value that depends on the input")
+	}
+	return val, nil
+}
+
+func (t *schemaTranslator) value(x *Expr, n Node) (interface{}, error) {
+	if n.Big != nil {
+		return json.Number(n.String()), nil
+	}
+	val, ok := jsonValue(n)
+	if !ok {
+		return nil, t.unsupported(x, "a "+n.Type.String()+" value")
+	}
+	return val, nil
+}
+
+func (t *schemaTranslator) compare(op TokenType, x *Expr) (object, error) {
+	n, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+	val, err := t.value(x, n)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case tokEQ:
+		return object{"const": val}, nil
+	case tokNEQ:
+		return object{"not": object{"const": val}}, nil
+	}
+
+	if n.Type != nodeInt && n.Type != nodeFloat {
+		return nil, t.unsupported(x, "ordering by a "+n.Type.String())
+	}
+	return object{"type": "number", schemaOps[op]: val}, nil
+}
+
+func (t *schemaTranslator) member(x *Expr) (object, error) {
+	n, err := t.constant(x)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Node{n}
+	switch n.Type {
+	case nodeList:
+		items = n.List.Items
+	case nodeRange:
+	default:
+		return nil, t.unsupported(x, "membership in a "+n.Type.String())
+	}
+
+	var ss []object
+	var vals []interface{}
+
+	for _, item := range items {
+		if item.Type != nodeRange {
+			val, err := t.value(x, item)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+			continue
+		}
+
+		r := item.Range
+		if r.Lo.Type != nodeInt && r.Lo.Type != nodeFloat {
+			return nil, t.unsupported(x, "a "+r.Lo.Type.String()+" range")
+		}
+		lo, err := t.value(x, r.Lo)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := t.value(x, r.Hi)
+		if err != nil {
+			return nil, err
+		}
+
+		loOp, hiOp := "minimum", "maximum"
+		if r.LoOpen {
+			loOp = "exclusiveMinimum"
+		}
+		if r.HiOpen {
+			hiOp = "exclusiveMaximum"
+		}
+		ss = append(ss, object{"type": "number", loOp: lo, hiOp: hi})
+	}
+
+	if len(vals) > 0 {
+		ss = append([]object{{"enum": vals}}, ss...)
+	}
+	if len(ss) == 0 {
+		return object{"not": object{}}, nil
+	}
+
+	s := ss[0]
+	for _, next := range ss[1:] {
+		s = t.anyOf(s, next)
+	}
+	return s, nil
+}
+
+// allOf merges a and b into one schema when none of their keywords conflict,
+// as in {"type": "number", "minimum": 1, "maximum": 400}.
+func (t *schemaTranslator) allOf(a, b object) object {
+	s := make(object, len(a)+len(b))
+	for k, v := range a {
+		s[k] = v
+	}
+	for k, v := range b {
+		if w, ok := s[k]; ok && !reflect.DeepEqual(v, w) {
+			return object{"allOf": append(t.flatten("allOf", a), t.flatten("allOf", b)...)}
+		}
+		s[k] = v
+	}
+	return s
+}
+
+// anyOf merges a and b into a single enum when both only list values.
+func (t *schemaTranslator) anyOf(a, b object) object {
+	if x, ok := t.enum(a); ok {
+		if y, ok := t.enum(b); ok {
+			return object{"enum": append(x, y...)}
+		}
+	}
+	return object{"anyOf": append(t.flatten("anyOf", a), t.flatten("anyOf", b)...)}
+}
+
+func (t *schemaTranslator) enum(s object) ([]interface{}, bool) {
+	if len(s) != 1 {
+		return nil, false
+	}
+	if val, ok := s["const"]; ok {
+		return []interface{}{val}, true
+	}
+	vals, ok := s["enum"].([]interface{})
+	return append([]interface{}(nil), vals...), ok
+}
+
+func (t *schemaTranslator) flatten(keyword string, s object) []object {
+	if ss, ok := s[keyword].([]object); ok && len(s) == 1 {
+		return ss
+	}
+	return []object{s}
+}
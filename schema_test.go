@@ -0,0 +1,68 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	cases := []struct {
+		rule     string
+		expected string
+	}{
+		{rule: `>=1 & <=400`, expected: `{"type": "number", "minimum": 1, "maximum": 400}`},
+		{rule: `> 0 & < 1.5`, expected: `{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1.5}`},
+		{rule: `"US"`, expected: `{"const": "US"}`},
+		{rule: `"US" | "CA" | null`, expected: `{"enum": ["US", "CA", null]}`},
+		{rule: `!= "a" & 1..3`, expected: `{"type": "number", "minimum": 1, "maximum": 3, "not": {"const": "a"}}`},
+		{
+			rule:     `in [1, 2, null, 5..7] & not in (1, 3]`,
+			expected: `{"anyOf": [{"enum": [1, 2, null]}, {"type": "number", "minimum": 5, "maximum": 7}], "not": {"type": "number", "exclusiveMinimum": 1, "maximum": 3}}`,
+		},
+		{rule: `~ "^a" | !(>= 10)`, expected: `{"anyOf": [{"type": "string", "pattern": "^a"}, {"not": {"type": "number", "minimum": 10}}]}`},
+		{rule: `required (>= 5)`, expected: `{"type": "number", "minimum": 5, "not": {"type": "null"}}`},
+		{rule: `optional <= 2 ** 10`, expected: `{"anyOf": [{"type": "null"}, {"type": "number", "maximum": 1024}]}`},
+		{
+			rule:     `age >= 18 & $'/a/b' ("x" | "y")`,
+			expected: `{"allOf": [{"required": ["age"], "properties": {"age": {"type": "number", "minimum": 18}}}, {"required": ["a"], "properties": {"a": {"required": ["b"], "properties": {"b": {"enum": ["x", "y"]}}}}}]}`,
+		},
+		{rule: `in []`, expected: `{"not": {}}`},
+		{rule: `age != 3`, expected: `{"properties": {"age": {"not": {"const": 3}}}}`},
+		{rule: `age optional >= 18`, expected: `{"properties": {"age": {"anyOf": [{"type": "null"}, {"type": "number", "minimum": 18}]}}}`},
+		{rule: `$'/a/b' null`, expected: `{"properties": {"a": {"properties": {"b": {"const": null}}}}}`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		s, err := px.JSONSchema()
+		require.NoError(t, err, test.rule)
+		require.JSONEq(t, test.expected, string(s), test.rule)
+	}
+}
+
+func TestJSONSchemaErrors(t *testing.T) {
+	cases := []struct {
+		rule string
+		err  string
+	}{
+		{rule: `> now`, err: `jsonschema: a time value is not supported in "now"`},
+		{rule: `in 2024-01-01..2024-02-01`, err: `jsonschema: a time range is not supported in "2024-01-01..2024-02-01"`},
+		{rule: `age (name == 1)`, err: `jsonschema: a field inside a subject is not supported in "name"`},
+		{rule: `== $.a`, err: `jsonschema: a value that depends on the input is not supported in "$.a"`},
+		{rule: `"a" + "b" == 1`, err: `jsonschema: a subject that is not a field is not supported in "\"a\" + \"b\""`},
+		{rule: `len($) > 1`, err: `jsonschema: a subject that is not a field is not supported in "len($)"`},
+		{rule: `in [1h]`, err: `jsonschema: a duration value is not supported in "[1h]"`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		_, err = px.JSONSchema()
+		require.True(t, errors.Is(err, ErrUnsupported), test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
@@ -0,0 +1,520 @@
+package boat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect customizes the SQL emitted by Rule.SQL.
+type Dialect interface {
+	String() string                                         // dialect name
+	Placeholder(n int) string                               // placeholder for the nth bound arg, starting at 1
+	Ident(name string) string                               // quoted identifier
+	Concat(a, b string) string                              // text concatenation
+	Distinct(a, b string) string                            // inequality that treats NULL as a value
+	Match(a, re string) (string, bool)                      // regular expression match, if supported
+	Xor(a, b string) (string, bool)                         // bitwise xor, if supported
+	Func(name string, args []string) (string, bool)         // call to a builtin func, if supported
+	AddDate(t string, months int64, d time.Duration) string // t shifted by a number of months and d
+}
+
+var (
+	Postgres Dialect = postgres{}
+	MySQL    Dialect = mysql{}
+	SQLite   Dialect = sqlite{}
+)
+
+var sqlOps = [...]string{
+	tokEQ:         "=",
+	tokNEQ:        "<>",
+	tokGT:         ">",
+	tokGTE:        ">=",
+	tokLT:         "<",
+	tokLTE:        "<=",
+	tokPlus:       "+",
+	tokMinus:      "-",
+	tokMultiply:   "*",
+	tokDivide:     "/",
+	tokModulo:     "%",
+	tokShiftLeft:  "<<",
+	tokShiftRight: ">>",
+	tokBitAnd:     "&",
+	tokBitOr:      "|",
+}
+
+// SQL translates the rule into a parameterized SQL predicate over column,
+// which is emitted as is. Identifiers in the rule are quoted as columns.
+// Negations and '!=' are null-safe, so a NULL column passes them as null does
+// in boat.
+func (e *Rule) SQL(column string, d Dialect) (string, []interface{}, error) {
+	t := sqlTranslator{rule: e.rule, column: column, d: d}
+	where, err := t.test(e.root)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, t.args, nil
+}
+
+type sqlTranslator struct {
+	rule   string        // rule
+	column string        // column the rule applies to
+	d      Dialect       // sql dialect
+	args   []interface{} // bound args
+	scope  []*Expr       // subjects in scope
+}
+
+func (t *sqlTranslator) unsupported(x *Expr, what string) error {
+	return unsupported(t.rule, t.d.String(), x, what)
+}
+
+func (t *sqlTranslator) bind(val interface{}) string {
+	t.args = append(t.args, val)
+	return t.d.Placeholder(len(t.args))
+}
+
+func (t *sqlTranslator) input() (string, error) {
+	n := len(t.scope)
+	if n == 0 {
+		return t.column, nil
+	}
+	x := t.scope[n-1]
+	t.scope = t.scope[:n-1]
+	in, err := t.value(x)
+	t.scope = t.scope[:n]
+	return in, err
+}
+
+func (t *sqlTranslator) test(x *Expr) (string, error) {
+	switch x.Type {
+	case tokAND, tokOR:
+		parts := make([]string, len(x.Args))
+		for i, arg := range x.Args {
+			p, err := t.test(arg)
+			if err != nil {
+				return "", err
+			}
+			if x.Type == tokAND && arg.Type == tokOR {
+				p = "(" + p + ")"
+			}
+			parts[i] = p
+		}
+		if x.Type == tokAND {
+			return strings.Join(parts, " AND "), nil
+		}
+		return strings.Join(parts, " OR "), nil
+	case tokBang:
+		p, err := t.test(x.Args[0])
+		if err != nil {
+			return "", err
+		}
+		return sqlNot(p), nil
+	case tokSubject:
+		t.scope = append(t.scope, x.Args[0])
+		p, err := t.test(x.Args[1])
+		t.scope = t.scope[:len(t.scope)-1]
+		return p, err
+	case tokRequired, tokOptional:
+		if x.Type == tokOptional && len(x.Args) == 0 {
+			return "TRUE", nil
+		}
+		in, err := t.input()
+		if err != nil {
+			return "", err
+		}
+		null, conn := in+" IS NOT NULL", " AND "
+		if x.Type == tokOptional {
+			null, conn = in+" IS NULL", " OR "
+		}
+		if len(x.Args) == 0 {
+			return null, nil
+		}
+		p, err := t.test(x.Args[0])
+		if err != nil {
+			return "", err
+		}
+		return "(" + null + conn + p + ")", nil
+	case tokEQ, tokNEQ, tokGT, tokGTE, tokLT, tokLTE:
+		return t.compare(x.Type, x.Args[0])
+	case tokMatch, tokNotMatch:
+		in, err := t.input()
+		if err != nil {
+			return "", err
+		}
+		p, ok := t.d.Match(in, t.bind(x.Args[0].Val.Text))
+		if !ok {
+			return "", t.unsupported(x, "regular expression matching")
+		}
+		if x.Type == tokNotMatch {
+			p = sqlNot(p)
+		}
+		return p, nil
+	case tokIn, tokNotIn:
+		p, err := t.member(x.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if x.Type == tokNotIn {
+			p = sqlNot(p)
+		}
+		return p, nil
+	}
+
+	switch {
+	case x.literal() && x.Val.Type == nodeBool:
+		if x.Val.Bool {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case x.literal() && (x.Val.Type == nodeList || x.Val.Type == nodeRange):
+		return t.member(x)
+	case !x.literal() && x.types.has(nodeBool):
+		return "", t.unsupported(x, "a test on a value of unknown type")
+	}
+
+	return t.compare(tokEQ, x)
+}
+
+func (t *sqlTranslator) compare(op TokenType, arg *Expr) (string, error) {
+	in, err := t.input()
+	if err != nil {
+		return "", err
+	}
+	if arg.literal() && arg.Val.Type == nodeNull {
+		switch op {
+		case tokEQ:
+			return in + " IS NULL", nil
+		case tokNEQ:
+			return in + " IS NOT NULL", nil
+		}
+	}
+	val, err := t.value(arg)
+	if err != nil {
+		return "", err
+	}
+	if op == tokNEQ {
+		return t.d.Distinct(in, val), nil
+	}
+	return in + " " + sqlOps[op] + " " + val, nil
+}
+
+// sqlNot negates p such that a NULL result, which boat treats as a failed test,
+// passes rather than dropping the row.
+func sqlNot(p string) string {
+	return "(" + p + ") IS NOT TRUE"
+}
+
+func (t *sqlTranslator) member(x *Expr) (string, error) {
+	if !x.literal() || x.Val.Type != nodeList && x.Val.Type != nodeRange {
+		return "", t.unsupported(x, "membership in a non-constant list or range")
+	}
+	if x.Val.Type == nodeRange {
+		return t.between(x.Val.Range)
+	}
+
+	var parts []string
+	var vals []Node
+	var null bool
+	var ranges []*Range
+
+	for _, item := range x.Val.List.Items {
+		switch item.Type {
+		case nodeNull:
+			null = true
+		case nodeRange:
+			ranges = append(ranges, item.Range)
+		default:
+			vals = append(vals, item)
+		}
+	}
+
+	if len(vals) > 0 {
+		in, err := t.input()
+		if err != nil {
+			return "", err
+		}
+		ps := make([]string, len(vals))
+		for i, val := range vals {
+			if ps[i], err = t.literal(x, val); err != nil {
+				return "", err
+			}
+		}
+		parts = append(parts, in+" IN ("+strings.Join(ps, ", ")+")")
+	}
+	if null {
+		in, err := t.input()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, in+" IS NULL")
+	}
+	for _, r := range ranges {
+		p, err := t.between(r)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, p)
+	}
+
+	switch len(parts) {
+	case 0:
+		return "FALSE", nil
+	case 1:
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", nil
+}
+
+func (t *sqlTranslator) between(r *Range) (string, error) {
+	in, err := t.input()
+	if err != nil {
+		return "", err
+	}
+	lo, err := t.literal(nil, r.Lo)
+	if err != nil {
+		return "", err
+	}
+	if !r.LoOpen && !r.HiOpen {
+		hi, err := t.literal(nil, r.Hi)
+		if err != nil {
+			return "", err
+		}
+		return in + " BETWEEN " + lo + " AND " + hi, nil
+	}
+
+	loOp, hiOp := ">=", "<="
+	if r.LoOpen {
+		loOp = ">"
+	}
+	if r.HiOpen {
+		hiOp = "<"
+	}
+
+	in2, err := t.input()
+	if err != nil {
+		return "", err
+	}
+	hi, err := t.literal(nil, r.Hi)
+	if err != nil {
+		return "", err
+	}
+	return "(" + in + " " + loOp + " " + lo + " AND " + in2 + " " + hiOp + " " + hi + ")", nil
+}
+
+func (t *sqlTranslator) literal(x *Expr, n Node) (string, error) {
+	switch n.Type {
+	case nodeNull:
+		return "NULL", nil
+	case nodeBool:
+		return t.bind(n.Bool), nil
+	case nodeInt, nodeFloat:
+		switch {
+		case n.Big != nil:
+			return t.bind(n.String()), nil
+		case n.Type == nodeInt:
+			return t.bind(n.Int), nil
+		}
+		return t.bind(n.Float), nil
+	case nodeText:
+		return t.bind(n.Text), nil
+	case nodeTime:
+		return t.bind(n.Time), nil
+	}
+	if x == nil {
+		return "", fmt.Errorf("%s: a %s bound is %w", t.d, n.Type, ErrUnsupported)
+	}
+	return "", t.unsupported(x, "a "+n.Type.String()+" value")
+}
+
+func (t *sqlTranslator) value(x *Expr) (string, error) {
+	switch x.Type {
+	case tokInput:
+		return t.input()
+	case tokNow:
+		return "CURRENT_TIMESTAMP", nil
+	case tokIdent:
+		return t.d.Ident(x.Val.Text), nil
+	case tokPointer:
+		return "", t.unsupported(x, "a json pointer")
+	case tokCall:
+		args := make([]string, len(x.Args))
+		for i, arg := range x.Args {
+			val, err := t.value(arg)
+			if err != nil {
+				return "", err
+			}
+			args[i] = val
+		}
+		if call, ok := t.d.Func(x.Val.Text, args); ok {
+			return call, nil
+		}
+		return "", t.unsupported(x, fmt.Sprintf("func %q", x.Val.Text))
+	case tokNegate, tokBitNot:
+		val, err := t.value(x.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if x.Type == tokNegate {
+			return "(-" + val + ")", nil
+		}
+		return "(~" + val + ")", nil
+	}
+
+	if x.literal() {
+		return t.literal(x, x.Val)
+	}
+
+	if !isBinaryOp(x.Type) || x.Type == tokAND || x.Type == tokOR || x.Type == tokRange {
+		return "", t.unsupported(x, fmt.Sprintf("'%s' as a value", x.Type))
+	}
+
+	if x.Type == tokPlus || x.Type == tokMinus {
+		for i, arg := range x.Args {
+			if !arg.literal() || arg.Val.Type != nodeDuration || x.Type == tokMinus && i == 0 {
+				continue
+			}
+			val, err := t.value(x.Args[1-i])
+			if err != nil {
+				return "", err
+			}
+			months, d := arg.Val.Months, time.Duration(arg.Val.Int)
+			if x.Type == tokMinus {
+				months, d = -months, -d
+			}
+			return t.d.AddDate(val, months, d), nil
+		}
+	}
+
+	if x.Type == tokMultiply && x.types == 1<<nodeText {
+		return "", t.unsupported(x, "repeating text")
+	}
+
+	a, err := t.value(x.Args[0])
+	if err != nil {
+		return "", err
+	}
+	b, err := t.value(x.Args[1])
+	if err != nil {
+		return "", err
+	}
+
+	switch x.Type {
+	case tokPlus:
+		if x.types == 1<<nodeText {
+			return t.d.Concat(a, b), nil
+		}
+	case tokPower:
+		return "POWER(" + a + ", " + b + ")", nil
+	case tokBitXor:
+		if xor, ok := t.d.Xor(a, b); ok {
+			return xor, nil
+		}
+		return "", t.unsupported(x, "'^^^'")
+	}
+
+	return "(" + a + " " + sqlOps[x.Type] + " " + b + ")", nil
+}
+
+func quoteIdent(name string, quote string) string {
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+func sqlFunc(name string, args []string, length string) (string, bool) {
+	switch name {
+	case "lower", "upper", "trim":
+		name = strings.ToUpper(name)
+	case "len":
+		name = length
+	default:
+		return "", false
+	}
+	return name + "(" + strings.Join(args, ", ") + ")", true
+}
+
+type postgres struct{}
+
+func (postgres) String() string                    { return "postgres" }
+func (postgres) Placeholder(n int) string          { return fmt.Sprintf("$%d", n) }
+func (postgres) Ident(name string) string          { return quoteIdent(name, `"`) }
+func (postgres) Concat(a, b string) string         { return "(" + a + " || " + b + ")" }
+func (postgres) Distinct(a, b string) string       { return a + " IS DISTINCT FROM " + b }
+func (postgres) Match(a, re string) (string, bool) { return a + " ~ " + re, true }
+func (postgres) Xor(a, b string) (string, bool)    { return "(" + a + " # " + b + ")", true }
+
+func (postgres) Func(name string, args []string) (string, bool) {
+	return sqlFunc(name, args, "CHAR_LENGTH")
+}
+
+func (postgres) AddDate(t string, months int64, d time.Duration) string {
+	var parts []string
+	if months != 0 {
+		parts = append(parts, fmt.Sprintf("%d months", months))
+	}
+	if d != 0 {
+		parts = append(parts, strconv.FormatFloat(d.Seconds(), 'f', -1, 64)+" seconds")
+	}
+	if len(parts) == 0 {
+		return t
+	}
+	return "(" + t + " + INTERVAL '" + strings.Join(parts, " ") + "')"
+}
+
+type mysql struct{}
+
+func (mysql) String() string                    { return "mysql" }
+func (mysql) Placeholder(n int) string          { return "?" }
+func (mysql) Ident(name string) string          { return quoteIdent(name, "`") }
+func (mysql) Concat(a, b string) string         { return "CONCAT(" + a + ", " + b + ")" }
+func (mysql) Distinct(a, b string) string       { return "NOT (" + a + " <=> " + b + ")" }
+func (mysql) Match(a, re string) (string, bool) { return a + " REGEXP " + re, true }
+func (mysql) Xor(a, b string) (string, bool)    { return "(" + a + " ^ " + b + ")", true }
+
+func (mysql) Func(name string, args []string) (string, bool) {
+	return sqlFunc(name, args, "CHAR_LENGTH")
+}
+
+func (mysql) AddDate(t string, months int64, d time.Duration) string {
+	if months == 0 && d == 0 {
+		return t
+	}
+	if months != 0 {
+		t += fmt.Sprintf(" + INTERVAL %d MONTH", months)
+	}
+	if d != 0 {
+		t += fmt.Sprintf(" + INTERVAL %d MICROSECOND", d.Microseconds())
+	}
+	return "(" + t + ")"
+}
+
+type sqlite struct{}
+
+func (sqlite) String() string                    { return "sqlite" }
+func (sqlite) Placeholder(n int) string          { return "?" }
+func (sqlite) Ident(name string) string          { return quoteIdent(name, `"`) }
+func (sqlite) Concat(a, b string) string         { return "(" + a + " || " + b + ")" }
+func (sqlite) Distinct(a, b string) string       { return a + " IS NOT " + b }
+func (sqlite) Match(a, re string) (string, bool) { return a + " REGEXP " + re, true }
+func (sqlite) Xor(a, b string) (string, bool)    { return "", false }
+
+func (sqlite) Func(name string, args []string) (string, bool) {
+	return sqlFunc(name, args, "LENGTH")
+}
+
+func (sqlite) AddDate(t string, months int64, d time.Duration) string {
+	if months == 0 && d == 0 {
+		return t
+	}
+	mods := []string{t}
+	if months != 0 {
+		mods = append(mods, fmt.Sprintf("'%+d months'", months))
+	}
+	if d != 0 {
+		sec := strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+		if d > 0 {
+			sec = "+" + sec
+		}
+		mods = append(mods, "'"+sec+" seconds'")
+	}
+	return "datetime(" + strings.Join(mods, ", ") + ")"
+}
@@ -0,0 +1,124 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQL(t *testing.T) {
+	cases := []struct {
+		rule     string
+		postgres string
+		mysql    string
+		sqlite   string
+		args     []interface{}
+	}{
+		{
+			rule:     `>=1 & <=400 | "US"`,
+			postgres: `col >= $1 AND col <= $2 OR col = $3`,
+			mysql:    `col >= ? AND col <= ? OR col = ?`,
+			sqlite:   `col >= ? AND col <= ? OR col = ?`,
+			args:     []interface{}{int64(1), int64(400), "US"},
+		},
+		{
+			rule:     `in [1, 2, null, 5..7] & not in (1, 3]`,
+			postgres: `(col IN ($1, $2) OR col IS NULL OR col BETWEEN $3 AND $4) AND ((col > $5 AND col <= $6)) IS NOT TRUE`,
+			mysql:    `(col IN (?, ?) OR col IS NULL OR col BETWEEN ? AND ?) AND ((col > ? AND col <= ?)) IS NOT TRUE`,
+			sqlite:   `(col IN (?, ?) OR col IS NULL OR col BETWEEN ? AND ?) AND ((col > ? AND col <= ?)) IS NOT TRUE`,
+			args:     []interface{}{int64(1), int64(2), int64(5), int64(7), int64(1), int64(3)},
+		},
+		{
+			rule:     `~ "^a" & !~ "b" & (1..5 | false)`,
+			postgres: `col ~ $1 AND (col ~ $2) IS NOT TRUE AND (col BETWEEN $3 AND $4 OR FALSE)`,
+			mysql:    `col REGEXP ? AND (col REGEXP ?) IS NOT TRUE AND (col BETWEEN ? AND ? OR FALSE)`,
+			sqlite:   `col REGEXP ? AND (col REGEXP ?) IS NOT TRUE AND (col BETWEEN ? AND ? OR FALSE)`,
+			args:     []interface{}{"^a", "b", int64(1), int64(5)},
+		},
+		{
+			rule:     `(required >= 5) | $ == null`,
+			postgres: `(col IS NOT NULL AND col >= $1) OR col IS NULL`,
+			mysql:    `(col IS NOT NULL AND col >= ?) OR col IS NULL`,
+			sqlite:   `(col IS NOT NULL AND col >= ?) OR col IS NULL`,
+			args:     []interface{}{int64(5)},
+		},
+		{
+			rule:     `len($) (>= 1 & <= 5) & lower(name) == "ab" + $`,
+			postgres: `CHAR_LENGTH(col) >= $1 AND CHAR_LENGTH(col) <= $2 AND LOWER("name") = ($3 || LOWER("name"))`,
+			mysql:    "CHAR_LENGTH(col) >= ? AND CHAR_LENGTH(col) <= ? AND LOWER(`name`) = CONCAT(?, LOWER(`name`))",
+			sqlite:   `LENGTH(col) >= ? AND LENGTH(col) <= ? AND LOWER("name") = (? || LOWER("name"))`,
+			args:     []interface{}{int64(1), int64(5), "ab"},
+		},
+		{
+			rule:     `> now - 18y & < now + 90m`,
+			postgres: `col > (CURRENT_TIMESTAMP + INTERVAL '-216 months') AND col < (CURRENT_TIMESTAMP + INTERVAL '5400 seconds')`,
+			mysql:    `col > (CURRENT_TIMESTAMP + INTERVAL -216 MONTH) AND col < (CURRENT_TIMESTAMP + INTERVAL 5400000000 MICROSECOND)`,
+			sqlite:   `col > datetime(CURRENT_TIMESTAMP, '-216 months') AND col < datetime(CURRENT_TIMESTAMP, '+5400 seconds')`,
+		},
+		{
+			rule:     `$ * 2 + 1 == age ** 2 & !(-$ > 2.5)`,
+			postgres: `((col * $1) + $2) = POWER("age", $3) AND ((-col) > $4) IS NOT TRUE`,
+			mysql:    "((col * ?) + ?) = POWER(`age`, ?) AND ((-col) > ?) IS NOT TRUE",
+			sqlite:   `((col * ?) + ?) = POWER("age", ?) AND ((-col) > ?) IS NOT TRUE`,
+			args:     []interface{}{int64(2), int64(1), int64(2), 2.5},
+		},
+		{
+			rule:     `$ + 1 in [1, null] & $ * 2 in [null]`,
+			postgres: `((col + $1) IN ($2) OR (col + $3) IS NULL) AND (col * $4) IS NULL`,
+			mysql:    `((col + ?) IN (?) OR (col + ?) IS NULL) AND (col * ?) IS NULL`,
+			sqlite:   `((col + ?) IN (?) OR (col + ?) IS NULL) AND (col * ?) IS NULL`,
+			args:     []interface{}{int64(1), int64(1), int64(1), int64(2)},
+		},
+		{
+			rule:     `!= 5 & !(> 5) & name != lower($)`,
+			postgres: `col IS DISTINCT FROM $1 AND (col > $2) IS NOT TRUE AND "name" IS DISTINCT FROM LOWER("name")`,
+			mysql:    "NOT (col <=> ?) AND (col > ?) IS NOT TRUE AND NOT (`name` <=> LOWER(`name`))",
+			sqlite:   `col IS NOT ? AND (col > ?) IS NOT TRUE AND "name" IS NOT LOWER("name")`,
+			args:     []interface{}{int64(5), int64(5)},
+		},
+		{
+			rule:     `> 2024-01-01`,
+			postgres: `col > $1`,
+			mysql:    `col > ?`,
+			sqlite:   `col > ?`,
+			args:     []interface{}{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		for d, expected := range map[Dialect]string{Postgres: test.postgres, MySQL: test.mysql, SQLite: test.sqlite} {
+			where, args, err := px.SQL("col", d)
+			require.NoError(t, err, test.rule)
+			require.Equal(t, expected, where, test.rule)
+			require.Equal(t, test.args, args, test.rule)
+		}
+	}
+}
+
+func TestSQLErrors(t *testing.T) {
+	cases := []struct {
+		rule    string
+		dialect Dialect
+		err     string
+	}{
+		{rule: `$ ^^^ 1 == 0`, dialect: SQLite, err: `sqlite: '^^^' is not supported in "$ ^^^ 1"`},
+		{rule: `$'/a' == 1`, dialect: Postgres, err: `postgres: a json pointer is not supported in "/a"`},
+		{rule: `substr($, 1) == "a"`, dialect: MySQL, err: `mysql: func "substr" is not supported in "substr($, 1)"`},
+		{rule: `active`, dialect: Postgres, err: `postgres: a test on a value of unknown type is not supported in "active"`},
+		{rule: `"ab" * n == $`, dialect: Postgres, err: `postgres: repeating text is not supported in "\"ab\" * n"`},
+		{rule: `== 1h`, dialect: Postgres, err: `postgres: a duration value is not supported in "1h"`},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		_, _, err = px.SQL("col", test.dialect)
+		require.True(t, errors.Is(err, ErrUnsupported), test.rule)
+		require.EqualError(t, err, test.err, test.rule)
+	}
+}
@@ -0,0 +1,235 @@
+package boat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type structKey struct {
+	typ  reflect.Type // struct type
+	path string       // dotted field path
+}
+
+var structCache sync.Map // map[structKey][]int
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+type structResolver struct {
+	val reflect.Value // struct value
+	big bool          // decode out of range numbers as big numbers?
+}
+
+func (e *Rule) EvalStruct(v interface{}) (bool, error) {
+	val, ok := indirect(reflect.ValueOf(v))
+	if !ok || val.Kind() != reflect.Struct {
+		return false, errors.New("eval struct: value must be a struct or a non-nil pointer to a struct")
+	}
+	return e.eval(Node{Type: nodeNull}, structResolver{val: val, big: e.big})
+}
+
+func (r structResolver) Resolve(name string) (Node, bool) {
+	n, err := r.resolve(name)
+	return n, err == nil
+}
+
+func (r structResolver) resolveError(name string) error {
+	_, err := r.resolve(name)
+	return err
+}
+
+func (r structResolver) resolve(name string) (Node, error) {
+	fields, ok := structFields(r.val.Type(), name)
+	if !ok {
+		return Node{}, unknownIdent(name)
+	}
+
+	val := r.val
+	for _, i := range fields {
+		val, ok = indirect(val)
+		if !ok {
+			return Node{Type: nodeNull}, nil
+		}
+		val = val.Field(i)
+	}
+
+	val, ok = indirect(val)
+	if !ok {
+		return Node{Type: nodeNull}, nil
+	}
+
+	if k := val.Kind(); k >= reflect.Uint && k <= reflect.Uintptr && val.Uint() > math.MaxInt64 && !r.big {
+		return Node{}, fmt.Errorf("field %q: %d needs WithBigNumbers: %w", name, val.Uint(), ErrOverflow)
+	}
+
+	n, ok := reflectNode(val, r.big)
+	if !ok {
+		return Node{}, unknownIdent(name)
+	}
+	return n, nil
+}
+
+func structFields(typ reflect.Type, path string) ([]int, bool) {
+	key := structKey{typ: typ, path: path}
+
+	if fields, ok := structCache.Load(key); ok {
+		return fields.([]int), fields.([]int) != nil
+	}
+
+	var fields []int
+
+	for _, name := range splitPath(path) {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			fields = nil
+			break
+		}
+		index, ok := structField(typ, name)
+		if !ok {
+			fields = nil
+			break
+		}
+		fields = append(fields, index...)
+		typ = typ.FieldByIndex(index).Type
+	}
+
+	structCache.Store(key, fields)
+
+	return fields, fields != nil
+}
+
+type embedded struct {
+	typ   reflect.Type // embedded struct type
+	index []int        // index sequence of the embedded field
+}
+
+// structField finds the field named name in typ, including fields promoted
+// from embedded structs. As in encoding/json, the shallowest field wins, a
+// tagged field wins over untagged ones at the same depth, and names that are
+// otherwise ambiguous are hidden.
+func structField(typ reflect.Type, name string) ([]int, bool) {
+	var fold []int
+
+	visited := make(map[reflect.Type]bool)
+
+	for next := []embedded{{typ: typ}}; len(next) > 0; {
+		current := next
+		next = nil
+
+		var tags, names [][]int
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				index := append(append([]int(nil), e.index...), i)
+
+				tag := f.Tag.Get("boat")
+				if idx := strings.IndexByte(tag, ','); idx >= 0 {
+					tag = tag[:idx]
+				}
+
+				if f.Anonymous && tag == "" {
+					t := f.Type
+					if t.Kind() == reflect.Ptr {
+						t = t.Elem()
+					}
+					if t.Kind() == reflect.Struct {
+						next = append(next, embedded{typ: t, index: index})
+						continue
+					}
+				}
+
+				switch {
+				case f.PkgPath != "", tag == "-":
+				case tag != "":
+					if tag == name {
+						tags = append(tags, index)
+					}
+				case f.Name == name:
+					names = append(names, index)
+				case fold == nil && strings.EqualFold(f.Name, name):
+					fold = index
+				}
+			}
+		}
+
+		switch {
+		case len(tags) == 1:
+			return tags[0], true
+		case len(tags) == 0 && len(names) == 1:
+			return names[0], true
+		case len(tags)+len(names) > 0:
+			return nil, false
+		}
+	}
+
+	return fold, fold != nil
+}
+
+func indirect(val reflect.Value) (reflect.Value, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return val, false
+		}
+		val = val.Elem()
+	}
+	return val, val.IsValid()
+}
+
+func reflectNode(val reflect.Value, bigNums bool) (Node, bool) {
+	switch val.Type() {
+	case bigIntType:
+		if !val.CanInterface() {
+			return Node{}, false
+		}
+		v := val.Interface().(big.Int)
+		return BigIntNode(&v), true
+	case bigRatType:
+		if !val.CanInterface() {
+			return Node{}, false
+		}
+		v := val.Interface().(big.Rat)
+		return DecimalNode(&v), true
+	case timeType:
+		if !val.CanInterface() {
+			return Node{}, false
+		}
+		return Node{Type: nodeTime, Time: val.Interface().(time.Time)}, true
+	case durationType:
+		return Node{Type: nodeDuration, Int: val.Int()}, true
+	}
+
+	switch val.Kind() {
+	case reflect.Bool:
+		return Node{Type: nodeBool, Bool: val.Bool()}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Node{Type: nodeInt, Int: val.Int()}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val.Uint() > math.MaxInt64 {
+			return BigIntNode(new(big.Int).SetUint64(val.Uint())), bigNums
+		}
+		return Node{Type: nodeInt, Int: int64(val.Uint())}, true
+	case reflect.Float32, reflect.Float64:
+		return Node{Type: nodeFloat, Float: val.Float()}, true
+	case reflect.String:
+		return Node{Type: nodeText, Text: val.String()}, true
+	}
+	return Node{}, false
+}
@@ -0,0 +1,192 @@
+package boat
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+type testAddress struct {
+	Zip     string `boat:"zip"`
+	Country string
+}
+
+type testUser struct {
+	Name    string `boat:"name"`
+	Age     uint8  `boat:"age"`
+	Score   float32
+	Admin   bool         `boat:"admin"`
+	Address *testAddress `boat:"address"`
+	Secret  string       `boat:"-"`
+	private int
+}
+
+type testOrder struct {
+	User  testUser `boat:"user"`
+	Total int64    `boat:"total"`
+}
+
+func TestEvalStruct(t *testing.T) {
+	order := testOrder{
+		User: testUser{
+			Name:    "kenta",
+			Age:     21,
+			Score:   7.5,
+			Address: &testAddress{Zip: "94103", Country: "US"},
+			Secret:  "hunter2",
+			private: 1,
+		},
+		Total: 250,
+	}
+
+	cases := []struct {
+		rule string
+		pass bool
+	}{
+		{rule: `user.age >= 18 & user.address.zip "94103"`, pass: true},
+		{rule: `user.name "kenta" & !user.admin`, pass: true},
+		{rule: `user.Score > 7 & user.score < 8`, pass: true},
+		{rule: `user.address.country "US" & user.address.Country "US"`, pass: true},
+		{rule: `total (>=100 & <=200)`, pass: false},
+		{rule: `required`, pass: false},
+		{rule: `$ == null & optional`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.EvalStruct(order)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+
+		pass, err = px.EvalStruct(&order)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestEvalStructUnresolved(t *testing.T) {
+	cases := []string{
+		`user.secret "hunter2"`,
+		`user.Secret "hunter2"`,
+		`user.private 1`,
+		`user.missing 1`,
+		`user.address 1`,
+		`user.address.zip.code 1`,
+	}
+
+	order := testOrder{User: testUser{Address: &testAddress{}}}
+
+	for _, test := range cases {
+		px, err := ParseRule(test)
+		require.NoError(t, err, test)
+
+		_, err = px.EvalStruct(order)
+		require.Error(t, err, test)
+	}
+
+	px, err := ParseRule(`user.address.zip == null & user.address.country == null`)
+	require.NoError(t, err)
+
+	pass, err := px.EvalStruct(testOrder{})
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	_, err = px.EvalStruct(42)
+	require.Error(t, err)
+}
+
+func TestEvalStructUint(t *testing.T) {
+	v := struct {
+		N uint64 `boat:"n"`
+	}{N: math.MaxUint64}
+
+	px, err := ParseRule(`n > 0`)
+	require.NoError(t, err)
+
+	_, err = px.EvalStruct(v)
+	require.True(t, errors.Is(err, ErrOverflow))
+	require.EqualError(t, err, `line 1, column 1: field "n": 18446744073709551615 needs WithBigNumbers: integer overflow`)
+
+	px, err = ParseRule(`n == 18446744073709551615`, WithBigNumbers())
+	require.NoError(t, err)
+
+	pass, err := px.EvalStruct(v)
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+type testBase struct {
+	ID      int64 `boat:"id"`
+	Created string
+	Name    string
+}
+
+type testAudit struct {
+	Created string
+	By      string
+}
+
+type testAccount struct {
+	testBase
+	*testAudit
+	Name string
+}
+
+func TestEvalStructEmbedded(t *testing.T) {
+	account := testAccount{
+		testBase:  testBase{ID: 1, Created: "base", Name: "base"},
+		testAudit: &testAudit{Created: "audit", By: "kenta"},
+		Name:      "outer",
+	}
+
+	cases := []struct {
+		rule string
+		pass bool
+	}{
+		{rule: `id == 1`, pass: true},
+		{rule: `Name "outer"`, pass: true},
+		{rule: `By "kenta" & by "kenta"`, pass: true},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule)
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.EvalStruct(account)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+
+	px, err := ParseRule(`Created "base"`)
+	require.NoError(t, err)
+
+	_, err = px.EvalStruct(account)
+	require.Error(t, err)
+
+	px, err = ParseRule(`By == null`)
+	require.NoError(t, err)
+
+	pass, err := px.EvalStruct(testAccount{})
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+func BenchmarkEvalStruct(b *testing.B) {
+	px, err := ParseRule(`user.age >= 18 & user.address.zip "94103"`)
+	require.NoError(b, err)
+
+	order := &testOrder{User: testUser{Age: 21, Address: &testAddress{Zip: "94103"}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		pass, err := px.EvalStruct(order)
+		if !pass || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package boat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// approximate length of a calendar month, used to order durations with calendar units
+const avgMonth = 365.2425 * 24 * float64(time.Hour) / 12
+
+var timeLiteral = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[Tt]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:[Zz]|[+-]\d{2}:\d{2}))?`)
+
+var durationUnits = map[string]struct {
+	nanos  int64 // length in nanoseconds
+	months int64 // length in calendar months
+}{
+	"ns": {nanos: 1},
+	"us": {nanos: int64(time.Microsecond)},
+	"µs": {nanos: int64(time.Microsecond)},
+	"ms": {nanos: int64(time.Millisecond)},
+	"s":  {nanos: int64(time.Second)},
+	"m":  {nanos: int64(time.Minute)},
+	"h":  {nanos: int64(time.Hour)},
+	"d":  {nanos: int64(24 * time.Hour)},
+	"w":  {nanos: int64(7 * 24 * time.Hour)},
+	"mo": {months: 1},
+	"y":  {months: 12},
+}
+
+func TimeNode(val time.Time) Node {
+	return Node{Type: nodeTime, Time: val}
+}
+
+func DurationNode(val time.Duration) Node {
+	return Node{Type: nodeDuration, Int: int64(val)}
+}
+
+func isTime(val string) bool {
+	if len(val) < 10 || val[4] != '-' || val[7] != '-' {
+		return false
+	}
+	loc := timeLiteral.FindStringIndex(val)
+	return loc != nil && loc[1] == len(val)
+}
+
+func parseTime(val string) (time.Time, error) {
+	if len(val) == 10 {
+		return time.Parse("2006-01-02", val)
+	}
+	return time.Parse(time.RFC3339Nano, strings.ToUpper(val))
+}
+
+func parseDuration(val string) (Node, error) {
+	n := Node{Type: nodeDuration}
+
+	for val != "" {
+		i := strings.IndexFunc(val, func(r rune) bool { return !isDecimalRune(r) && r != '.' && r != '_' })
+		if i <= 0 {
+			return n, fmt.Errorf("invalid duration %q", val)
+		}
+		num := strings.Replace(val[:i], "_", "", -1)
+		val = val[i:]
+
+		j := strings.IndexFunc(val, isDecimalRune)
+		if j < 0 {
+			j = len(val)
+		}
+		unit, ok := durationUnits[val[:j]]
+		if !ok {
+			return n, fmt.Errorf("unknown duration unit %q", val[:j])
+		}
+		val = val[j:]
+
+		if unit.months != 0 {
+			v, err := strconv.ParseInt(num, 10, 64)
+			if err != nil {
+				return n, fmt.Errorf("calendar duration must be a whole number: %w", err)
+			}
+			if v > math.MaxInt32 {
+				return n, errors.New("duration out of range")
+			}
+			n.Months += v * unit.months
+			continue
+		}
+
+		v, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return n, err
+		}
+		v = v*float64(unit.nanos) + float64(n.Int)
+		if v >= math.MaxInt64 {
+			return n, errors.New("duration out of range")
+		}
+		n.Int = int64(v)
+	}
+
+	return n, nil
+}
+
+func formatDuration(n Node) string {
+	var b strings.Builder
+
+	months, nanos := n.Months, n.Int
+	if months < 0 || months == 0 && nanos < 0 {
+		b.WriteByte('-')
+		months, nanos = -months, -nanos
+	}
+
+	if y := months / 12; y != 0 {
+		b.WriteString(strconv.FormatInt(y, 10) + "y")
+	}
+	if mo := months % 12; mo != 0 {
+		b.WriteString(strconv.FormatInt(mo, 10) + "mo")
+	}
+	if nanos != 0 || months == 0 {
+		b.WriteString(time.Duration(nanos).String())
+	}
+
+	return b.String()
+}
+
+func addDuration(t time.Time, d Node, sign int64) time.Time {
+	if d.Months != 0 {
+		t = t.AddDate(0, int(sign*d.Months), 0)
+	}
+	return t.Add(time.Duration(sign * d.Int))
+}
+
+func compareDurations(a, b Node) int {
+	if a.Months == b.Months {
+		return compareInts(a.Int, b.Int)
+	}
+	c, _ := compareFloats(float64(a.Months)*avgMonth+float64(a.Int), float64(b.Months)*avgMonth+float64(b.Int))
+	return c
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func timeOf(n Node) (time.Time, bool) {
+	switch n.Type {
+	case nodeTime:
+		return n.Time, true
+	case nodeText:
+		if !isTime(n.Text) {
+			return time.Time{}, false
+		}
+		t, err := parseTime(n.Text)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
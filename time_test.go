@@ -0,0 +1,135 @@
+package boat
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func testClock() time.Time {
+	return testNow
+}
+
+func TestTimeRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		rule string
+		pass bool
+	}{
+		{in: "2000-01-01", rule: `<= now - 18y`, pass: true},
+		{in: "2006-03-16", rule: `<= now - 18y`, pass: false},
+		{in: "2006-03-15T12:00:00Z", rule: `<= now - 18y`, pass: true},
+		{in: "2024-03-15T11:30:00Z", rule: `> now - 1h`, pass: true},
+		{in: "2024-03-15T10:30:00Z", rule: `> now - 1h`, pass: false},
+		{in: "2024-03-15T13:30:00+02:00", rule: `> now - 1h`, pass: true},
+		{in: "2024-03-15T12:00:00.5Z", rule: `> 2024-03-15T12:00:00Z`, pass: true},
+		{in: "2024-03-15", rule: `2024-03-15T00:00:00Z`, pass: true},
+		{in: "2024-03-15", rule: `2024-01-01..2024-12-31`, pass: true},
+		{in: "2023-03-15", rule: `2024-01-01..2024-12-31`, pass: false},
+		{in: "2024-03-15", rule: `in [2024-03-15, 2024-03-16]`, pass: true},
+		{in: "2024-03-15", rule: `$ + 1mo == 2024-04-15`, pass: true},
+		{in: "2024-03-15", rule: `now - $ == 12h`, pass: true},
+		{in: "2024-03-15", rule: `now - $ > 11h59m59s`, pass: true},
+		{in: "2024-03-15", rule: `1d + $ == 2024-03-16`, pass: true},
+		{in: "2024-03-15", rule: `now - 2024-03-14T12:00:00Z == 1d`, pass: true},
+		{in: "2024-03-15", rule: `now - $ > 11h & now - $ < 1d & now - $ == 720m & now - $ == 0.5d`, pass: true},
+		{in: "2024-03-15", rule: `$ - now < 0s & $ - now == -12h & $ - now == 30m - 12h30m`, pass: true},
+		{in: "", rule: `now == now & now - 1h..now + 1h == now - 1h..now + 1h`, pass: true},
+		{in: "", rule: `now in now - 1h..now + 1h`, pass: true},
+		{in: "", rule: `now - 1h in [2024-03-15T10:00:00Z, 2024-03-15T11:00:00Z)`, pass: false},
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test.rule, WithClock(testClock))
+		require.NoError(t, err, test.rule)
+
+		pass, err := px.Eval(test.in)
+		require.NoError(t, err, test.rule)
+		require.EqualValues(t, test.pass, pass, test.rule)
+	}
+}
+
+func TestTimeDecode(t *testing.T) {
+	n, err := Decode("2024-03-15T12:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, nodeTime, n.Type)
+	require.True(t, n.Time.Equal(testNow))
+
+	n, err = Decode("2024-03-15")
+	require.NoError(t, err)
+	require.Equal(t, nodeTime, n.Type)
+
+	_, err = Decode("2024-13-15")
+	require.Error(t, err)
+}
+
+func TestTimeEnv(t *testing.T) {
+	px, err := ParseRule(`birthdate <= now - 18y & expires > now & ttl <= 5m`, WithClock(testClock))
+	require.NoError(t, err)
+
+	pass, err := px.EvalEnv("", Env{
+		"birthdate": TimeNode(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)),
+		"expires":   TextNode("2025-01-01T00:00:00Z"),
+		"ttl":       DurationNode(time.Minute),
+	})
+	require.NoError(t, err)
+	require.True(t, pass)
+
+	pass, err = px.EvalStruct(struct {
+		Birthdate time.Time
+		Expires   *time.Time
+		TTL       time.Duration
+	}{time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), &testNow, time.Second})
+	require.NoError(t, err)
+	require.False(t, pass)
+
+	px, err = ParseRule(`d + 250ms < 1s & d + 36h > 1d & d + 1.5h == 90m & d + 1w == 7d & d + 12mo == 1y & d + 1y in 364d..366d`)
+	require.NoError(t, err)
+
+	pass, err = px.EvalEnv("", Env{"d": DurationNode(0)})
+	require.NoError(t, err)
+	require.True(t, pass)
+}
+
+func TestTimeFormat(t *testing.T) {
+	cases := []struct {
+		rule string
+		out  string
+	}{
+		{rule: `18y`, out: `18y`},
+		{rule: `1y6mo`, out: `1y6mo`},
+		{rule: `1h30m`, out: `1h30m0s`},
+		{rule: `250ms`, out: `250ms`},
+		{rule: `0s`, out: `0s`},
+		{rule: `2024-03-15T12:00:00Z`, out: `2024-03-15T12:00:00Z`},
+	}
+
+	for _, test := range cases {
+		x, err := parse(test.rule, nil, false)
+		require.NoError(t, err, test.rule)
+		require.Equal(t, test.out, x.Val.String(), test.rule)
+	}
+}
+
+func TestTimeErrors(t *testing.T) {
+	cases := []string{
+		`5parsecs`,
+		`1.5y`,
+		`2024-13-01`,
+		`2024-01-01T25:00:00Z`,
+		`now + now`,
+		`1h + 5`,
+		`"a" - 1h`,
+	}
+
+	for _, test := range cases {
+		px, err := ParseRule(test, WithClock(testClock))
+		if err != nil {
+			continue
+		}
+		_, err = px.Eval("2024-01-01")
+		require.Error(t, err, test)
+	}
+}
@@ -12,18 +12,52 @@ const (
 	tokLT
 	tokLTE
 	tokBang
+	tokEQ
+	tokNEQ
+	tokMatch
+	tokNotMatch
+	tokIn
+	tokNotIn
 	tokAND
 	tokOR
 	tokPlus
 	tokMinus
 	tokMultiply
 	tokDivide
+	tokModulo
+	tokPower
+	tokShiftLeft
+	tokShiftRight
+	tokBitAnd
+	tokBitOr
+	tokBitXor
+	tokBitNot
+	tokRange
 	tokNegate
 	tokText
 	tokInt
 	tokFloat
+	tokTrue
+	tokFalse
+	tokNull
+	tokTime
+	tokDuration
+	tokNow
+	tokWeekday
+	tokRequired
+	tokOptional
 	tokBracketStart
 	tokBracketEnd
+	tokListStart
+	tokListEnd
+	tokComma
+	tokIdent
+	tokInput
+	tokPointer
+	tokSubject
+	tokCall
+	tokList
+	tokInterval
 )
 
 var tokStr = [...]string{
@@ -33,18 +67,62 @@ var tokStr = [...]string{
 	tokLT:           "<",
 	tokLTE:          "<=",
 	tokBang:         "!",
+	tokEQ:           "==",
+	tokNEQ:          "!=",
+	tokMatch:        "~",
+	tokNotMatch:     "!~",
+	tokIn:           "in",
+	tokNotIn:        "not in",
 	tokAND:          "&",
 	tokOR:           "|",
 	tokPlus:         "+",
 	tokMinus:        "-",
 	tokMultiply:     "*",
 	tokDivide:       "/",
+	tokModulo:       "%",
+	tokPower:        "**",
+	tokShiftLeft:    "<<",
+	tokShiftRight:   ">>",
+	tokBitAnd:       "&&&",
+	tokBitOr:        "|||",
+	tokBitXor:       "^^^",
+	tokBitNot:       "~~~",
+	tokRange:        "..",
 	tokNegate:       "-",
 	tokText:         "text",
 	tokInt:          "int",
 	tokFloat:        "float",
+	tokTrue:         "true",
+	tokFalse:        "false",
+	tokNull:         "null",
+	tokTime:         "time",
+	tokDuration:     "duration",
+	tokNow:          "now",
+	tokWeekday:      "weekday",
+	tokRequired:     "required",
+	tokOptional:     "optional",
 	tokBracketStart: "(",
 	tokBracketEnd:   ")",
+	tokListStart:    "[",
+	tokListEnd:      "]",
+	tokComma:        ",",
+	tokIdent:        "ident",
+	tokInput:        "$",
+	tokPointer:      "pointer",
+	tokSubject:      "subject",
+	tokCall:         "call",
+	tokList:         "list",
+	tokInterval:     "interval",
+}
+
+var keywords = map[string]TokenType{
+	"true":     tokTrue,
+	"false":    tokFalse,
+	"null":     tokNull,
+	"required": tokRequired,
+	"optional": tokOptional,
+	"in":       tokIn,
+	"now":      tokNow,
 }
 
 func (t TokenType) String() string {
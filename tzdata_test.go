@@ -0,0 +1,6 @@
+//go:build go1.15
+// +build go1.15
+
+package boat
+
+import _ "time/tzdata"
@@ -0,0 +1,91 @@
+package boat
+
+import (
+	"fmt"
+	"time"
+)
+
+func (e *Rule) run(in Node, env Resolver) error {
+	e.vals = e.vals[:0]
+	e.ins = e.ins[:0]
+
+	for pc := 0; pc < len(e.code); pc++ {
+		ins := e.code[pc]
+
+		switch ins.op {
+		case opConst:
+			e.vals = append(e.vals, e.consts[ins.arg])
+		case opCompare, opArith:
+			if err := e.EvalOP(in, TokenType(ins.arg)); err != nil {
+				return e.errorAt(pc, fmt.Errorf("error while evaluating op: %w", err))
+			}
+		case opTruth:
+			i := len(e.vals) - 1
+			e.vals[i] = Node{Type: nodeBool, Bool: EvalNode(in, e.vals[i])}
+		case opJumpIfFalse:
+			if !e.vals[len(e.vals)-1].Bool {
+				pc = ins.arg - 1
+				continue
+			}
+			e.vals = e.vals[:len(e.vals)-1]
+		case opJumpIfTrue:
+			if e.vals[len(e.vals)-1].Bool {
+				pc = ins.arg - 1
+				continue
+			}
+			e.vals = e.vals[:len(e.vals)-1]
+		case opInput:
+			e.vals = append(e.vals, in)
+		case opLoad:
+			name := e.consts[ins.arg].Text
+			if env == nil {
+				return e.errorAt(pc, unknownIdent(name))
+			}
+			val, ok := env.Resolve(name)
+			if !ok {
+				return e.errorAt(pc, unresolved(env, name))
+			}
+			e.vals = append(e.vals, val)
+		case opScope:
+			e.ins = append(e.ins, in)
+			in = e.vals[len(e.vals)-1]
+			e.vals = e.vals[:len(e.vals)-1]
+		case opUnscope:
+			in = e.ins[len(e.ins)-1]
+			e.ins = e.ins[:len(e.ins)-1]
+		case opRequired, opOptional:
+			if in.Type == nodeNull {
+				e.vals = append(e.vals, Node{Type: nodeBool, Bool: ins.op == opOptional})
+				pc = ins.arg - 1
+				continue
+			}
+		case opCall:
+			c := e.calls[ins.arg]
+			i := len(e.vals) - c.argc
+			val, err := c.fn(e.vals[i:]...)
+			if err != nil {
+				return e.errorAt(pc, fmt.Errorf("error calling %s: %w", c.name, err))
+			}
+			e.vals = append(e.vals[:i], val)
+		case opNow:
+			e.vals = append(e.vals, Node{Type: nodeTime, Time: e.now()})
+		case opNot:
+			i := len(e.vals) - 1
+			e.vals[i].Bool = !e.vals[i].Bool
+		}
+	}
+
+	return nil
+}
+
+func (e *Rule) now() time.Time {
+	if e.clock != nil {
+		return e.clock()
+	}
+	return time.Now()
+}
+
+func (e *Rule) errorAt(pc int, err error) error {
+	start, end := span(e.exprs[pc])
+	return &EvalError{Position: position(e.rule, start, end), Err: err}
+}